@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+var AppJWTDuration = 9 * time.Minute
+var AppMediaType = "application/vnd.github.machine-man-preview+json"
+
+// appTokenSource provides GitHub App installation tokens, it is meant to be
+// wrapped in oauth2.ReuseTokenSource so that a new installation token is
+// requested only when the previous one expires.
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	transport      http.RoundTripper
+}
+
+func newAppTokenSource(appID, installationID int64, keyFile string, transport http.RoundTripper) (*appTokenSource, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("No PEM private key found in %s", keyFile)
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, fmt.Errorf("Invalid private key in %s: %v", keyFile, err)
+		}
+
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("Private key in %s is not an RSA key", keyFile)
+		}
+
+		key = rsaKey
+	}
+
+	return &appTokenSource{appID, installationID, key, transport}, nil
+}
+
+func (a *appTokenSource) jwt() (string, error) {
+	now := time.Now()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	// The issued time is set in the past to allow for clock drift
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(AppJWTDuration).Unix(),
+		"iss": a.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (a *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := a.jwt()
+	if err != nil {
+		return nil, err
+	}
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: jwt})
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: a.transport})
+
+	client, err := newGitHubClient(oauth2.NewClient(ctx, ts))
+	if err != nil {
+		return nil, err
+	}
+
+	// Apps.CreateInstallationToken uses an endpoint that is not available anymore
+	req, err := client.NewRequest("POST", fmt.Sprintf("app/installations/%d/access_tokens", a.installationID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", AppMediaType)
+
+	token := &github.InstallationToken{}
+
+	_, err = client.Do(context.Background(), req, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{AccessToken: token.GetToken(), Expiry: token.GetExpiresAt()}, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+)
+
+// cacheTransport stores the GET responses carrying an ETag in dir and
+// revalidates them with If-None-Match, a 304 Not Modified response is then
+// replaced with the stored one. Entries never expire: they are revalidated
+// on each request and overwritten when the content changes.
+type cacheTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func newCacheTransport(dir string, next http.RoundTripper) (*cacheTransport, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cacheTransport{dir, next}, nil
+}
+
+// cachePath includes the credentials and the media type in the key, as they
+// change the content of the responses.
+func (t *cacheTransport) cachePath(req *http.Request) string {
+	key := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Authorization") + "\n" + req.Header.Get("Accept")))
+	return filepath.Join(t.dir, fmt.Sprintf("%x", key))
+}
+
+func (t *cacheTransport) load(req *http.Request, path string) *http.Response {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil
+	}
+
+	return resp
+}
+
+func (t *cacheTransport) store(resp *http.Response, path string) error {
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	path := t.cachePath(req)
+	cached := t.load(req, path)
+
+	if cached != nil {
+		if etag := cached.Header.Get("ETag"); len(etag) > 0 {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", etag)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+
+		// The fresh headers carry the current rate limits
+		for k, v := range resp.Header {
+			cached.Header[k] = v
+		}
+
+		logger.Debugf("Using cached response for %s", req.URL)
+
+		return cached, nil
+	}
+
+	if cached != nil {
+		cached.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusOK && len(resp.Header.Get("ETag")) > 0 {
+		// DumpResponse reads the body and replaces it with an equivalent one
+		err := t.store(resp, path)
+		if err != nil {
+			logger.Infof("Cannot cache response for %s: %v", req.URL, err)
+		}
+	}
+
+	return resp, nil
+}
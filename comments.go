@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/google/go-github/github"
+	"io.bytenix.com/ghdump/ghdump"
+)
+
+var CommentsHeader = []string{"Repository", "Issue Number", "Comment Author", "Comment Created At", "Comment Body"}
+
+// commentsWriter writes one row for each comment of the dumped items, the
+// comments are listed with one or more additional requests for each item.
+type commentsWriter struct {
+	w *csv.Writer
+}
+
+func newCommentsWriter(w io.Writer, comma rune) *commentsWriter {
+	c := csv.NewWriter(w)
+	c.Comma = comma
+
+	return &commentsWriter{c}
+}
+
+func (c *commentsWriter) WriteHeader() error {
+	return c.w.Write(CommentsHeader)
+}
+
+func (c *commentsWriter) Write(ctx context.Context, client *github.Client, item *Item) error {
+	// The comments count avoids listing the items without comments
+	if item.Comments == 0 {
+		return nil
+	}
+
+	opts := dumpOptions("")
+	opts.Owner, opts.Repository = splitRepository(item.Repository)
+
+	return ghdump.IterateComments(ctx, client, opts, item.Number, func(comment *github.IssueComment) error {
+		author := UnknownUser
+
+		if comment.User != nil {
+			author = comment.User.GetLogin()
+		}
+
+		createdAt := comment.GetCreatedAt()
+
+		return c.w.Write([]string{item.Repository, fmt.Sprint(item.Number), author, formatTime(&createdAt), comment.GetBody()})
+	})
+}
+
+func (c *commentsWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *commentsWriter) Close() error {
+	return c.Flush()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configKey normalizes the config keys and the CmdFlags field names so that
+// e.g. "max_retries", "max-retries" and "MaxRetries" are equivalent.
+func configKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+// configFlags maps the CmdFlags field names and the flag names to the flags
+func configFlags() map[string]*flag.Flag {
+	fields := map[uintptr]string{}
+
+	v := reflect.ValueOf(&CmdFlags).Elem()
+
+	for n := 0; n < v.NumField(); n++ {
+		fields[v.Field(n).Addr().Pointer()] = v.Type().Field(n).Name
+	}
+
+	flags := map[string]*flag.Flag{}
+
+	flag.VisitAll(func(f *flag.Flag) {
+		flags[configKey(f.Name)] = f
+
+		// The flag values are pointers to the CmdFlags fields
+		if name, ok := fields[reflect.ValueOf(f.Value).Pointer()]; ok {
+			flags[configKey(name)] = f
+		}
+	})
+
+	return flags
+}
+
+// configValue returns the value of a scalar as written in the file (e.g.
+// dates are not converted), lists are returned as comma-separated values.
+func configValue(node *yaml.Node) string {
+	if node.Kind == yaml.SequenceNode {
+		values := make([]string, len(node.Content))
+
+		for n, v := range node.Content {
+			values[n] = v.Value
+		}
+
+		return strings.Join(values, ",")
+	}
+
+	return node.Value
+}
+
+// loadConfig sets the flags that are not specified on the command line from
+// the YAML (or JSON) config file at path.
+func loadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	config := map[string]yaml.Node{}
+
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return fmt.Errorf("Invalid config file %s: %v", path, err)
+	}
+
+	set := map[string]bool{}
+
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	flags := configFlags()
+
+	for key, node := range config {
+		f, ok := flags[configKey(key)]
+		if !ok || f.Name == "config" {
+			return fmt.Errorf("Invalid config file %s: unknown key %q", path, key)
+		}
+
+		if set[f.Name] {
+			continue
+		}
+
+		// The config values count as set, as the command line ones
+		err := flag.Set(f.Name, configValue(&node))
+		if err != nil {
+			return fmt.Errorf("Invalid config file %s: invalid value for %q: %v", path, key, err)
+		}
+	}
+
+	return nil
+}
+
+// expandEnvFlags expands the environment variables in the string flags, the
+// templates are excluded as their variables use the same $ syntax.
+func expandEnvFlags() {
+	flag.VisitAll(func(f *flag.Flag) {
+		g, ok := f.Value.(flag.Getter)
+		if !ok || f.Name == "template" {
+			return
+		}
+
+		if v, ok := g.Get().(string); ok && strings.Contains(v, "$") {
+			flag.Set(f.Name, os.ExpandEnv(v))
+		}
+	})
+}
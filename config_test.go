@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigSetsFlags(t *testing.T) {
+	since := CmdFlags.Since
+	defer func() { CmdFlags.Since = since }()
+
+	path := filepath.Join(t.TempDir(), "ghdump.yaml")
+
+	err := os.WriteFile(path, []byte("since: 2018-01-01\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if CmdFlags.Since != "2018-01-01" {
+		t.Errorf("Since is %q, expected 2018-01-01", CmdFlags.Since)
+	}
+
+	set := false
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "s" {
+			set = true
+		}
+	})
+
+	if !set {
+		t.Errorf("The -s flag of the config file is not set")
+	}
+}
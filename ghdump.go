@@ -1,155 +1,2314 @@
 package main
 
 import (
+	"bufio"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
 	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
+	"io.bytenix.com/ghdump/ghdump"
 )
 
-var GitHubTokenEnvVarName = "GITHUBTOKEN"
+// The token is read from the first non-empty variable, GH_TOKEN and
+// GITHUB_TOKEN are set by the gh CLI and by GitHub Actions.
+var GitHubTokenEnvVarNames = []string{"GITHUBTOKEN", "GH_TOKEN", "GITHUB_TOKEN"}
 var GitHubPasswordEnvVarName = "GITHUBPASSWORD"
+var GitHubOTPEnvVarName = "GITHUBOTP"
+var GitHubOAuthScopesHeader = "X-OAuth-Scopes"
+var GitHubAPIHost = "api.github.com"
 
 var GoogleSheetDateFormat = "01/02/2006 15:04:07"
-var GitHubMaxItemsPerPage = 100
 var CmdFlagsSinceFormat = "2006-01-02"
 
+// RelativeDateRegexp matches the relative -s/-until dates, e.g. 7d or 3mo
+var RelativeDateRegexp = regexp.MustCompile(`^([0-9]+)(d|w|mo|y)$`)
+var ListSeparator = "|"
+
+var newlinesNormalizer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+var newlinesEscaper = strings.NewReplacer("\r\n", "\\n", "\r", "\\n", "\n", "\\n")
+
+// UTF8BOM makes Excel read the CSV output as UTF-8
+var UTF8BOM = "\ufeff"
+
+// LinkedIssuesRegexp matches the closing keywords of the pull requests bodies,
+// e.g. "Fixes #123", the cross-repository references are not matched
+var LinkedIssuesRegexp = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+#([0-9]+)\b`)
+
+var StateValues = []string{"open", "closed", "all"}
+var SortValues = []string{"created", "updated", "comments"}
+var DirectionValues = []string{"desc", "asc"}
+var WindowDateValues = []string{"created", "closed", "updated", "merged"}
+var OwnerTypeValues = []string{"auto", "org", "user"}
+var DatePresetValues = []string{"us", "eu", "iso", "sheets"}
+
+// DatePresets are the -date-format layouts of the -date-preset names
+var DatePresets = map[string]string{
+	"us":     "01/02/2006 15:04:05",
+	"eu":     "02/01/2006 15:04:05",
+	"iso":    "2006-01-02 15:04:05",
+	"sheets": GoogleSheetDateFormat,
+}
+var OwnerTypeNames = map[string]string{"auto": "Organization or user", "org": "Organization", "user": "User"}
+
 var TypePullRequest = "Pull Request"
 var TypeIssue = "Issue"
+var UnknownUser = "(unknown)"
+
+var progress *progressReporter
+var resume *resumeState
 
 var CmdFlags = struct {
-	Username     string
-	Password     string
-	NoLogin      bool
-	TabSeparated bool
-	Organization string
-	Repository   string
-	Since        string
+	Username         string
+	Password         string
+	OTP              string
+	NoLogin          bool
+	TabSeparated     bool
+	JSON             bool
+	Output           string
+	Header           bool
+	Organization     string
+	Repository       string
+	Since            string
+	Until            string
+	MaxRetries       int
+	Labels           string
+	APIURL           string
+	State            string
+	AllRepos         bool
+	IncludeForks     bool
+	IncludeArchived  bool
+	Timeout          time.Duration
+	Assignee         string
+	Milestone        string
+	DateFormat       string
+	NoHyperlink      bool
+	TokenFile        string
+	Concurrency      int
+	Author           string
+	XLSX             bool
+	Summary          bool
+	Body             bool
+	Sort             string
+	Direction        string
+	Progress         bool
+	AppID            int64
+	InstallationID   int64
+	AppKeyFile       string
+	Quiet            bool
+	Verbose          bool
+	Reactions        bool
+	ExcludePRs       bool
+	OnlyPRs          bool
+	ResumeFile       string
+	CacheDir         string
+	SQLite           string
+	Markdown         bool
+	Query            string
+	Limit            int
+	SinceMilestone   string
+	Config           string
+	Fields           string
+	Reviews          bool
+	MergedOnly       bool
+	Branches         bool
+	DryRun           bool
+	Age              bool
+	OwnerType        string
+	GroupBy          string
+	CommentsOutput   string
+	RepoURL          bool
+	FlushEvery       int
+	Template         string
+	TemplateFile     string
+	ShowRateLimit    bool
+	Commits          bool
+	LabelColors      bool
+	ContinueOnError  bool
+	Gzip             bool
+	TitleContains    string
+	SplitByAuthor    bool
+	OutputDir        string
+	Draft            bool
+	ExcludeDrafts    bool
+	JSONArray        bool
+	LinkTitle        bool
+	RepoFile         string
+	ExpandEnv        bool
+	Participants     bool
+	OutputOrder      string
+	MilestoneDetails bool
+	Distribution     string
+	ExcludeBots      bool
+	ExcludeAuthors   string
+	StateFile        string
+	Pretty           bool
+	TitleWidth       int
+	GraphQL          bool
+	PerPage          int
+	LinkedIssues     bool
+	BOM              bool
+	Project          int
+	ProjectColumn    string
+	NormalizeNewline bool
+	EscapeNewlines   bool
+	Stats            bool
+	Delimiter        string
+	FromNumber       int
+	ToNumber         int
+	NodeID           bool
+	DatePreset       string
+	ClientCert       string
+	ClientKey        string
+	WindowDate       string
+	MinComments      int
+	NoCache          bool
 }{}
 
 func init() {
 	// By default we retrieve only last month
 	since := time.Now().AddDate(0, -1, 0).Format(CmdFlagsSinceFormat)
 
+	flag.StringVar(&CmdFlags.Config, "config", "", "Read the flags not specified on the command line from the specified YAML or JSON file")
+	flag.BoolVar(&CmdFlags.ExpandEnv, "expand-env", false, "Expand the environment variables (e.g. $REPO) in the flag values")
 	flag.StringVar(&CmdFlags.Username, "u", "", "GitHub username")
+	flag.StringVar(&CmdFlags.OTP, "otp", "", "GitHub two-factor authentication one-time password (or "+GitHubOTPEnvVarName+")")
+	flag.Int64Var(&CmdFlags.AppID, "app-id", 0, "GitHub App ID used for authentication")
+	flag.Int64Var(&CmdFlags.InstallationID, "installation-id", 0, "GitHub App installation ID used for authentication")
+	flag.StringVar(&CmdFlags.AppKeyFile, "app-key-file", "", "GitHub App private key file used for authentication")
+	flag.StringVar(&CmdFlags.TokenFile, "token-file", "", "Read the GitHub token from the specified file")
 	flag.BoolVar(&CmdFlags.NoLogin, "n", false, "Do not authenticate (could trigger API rate limits)")
 	flag.BoolVar(&CmdFlags.TabSeparated, "t", false, "Use tab-separated output")
+	flag.StringVar(&CmdFlags.Delimiter, "delimiter", "", "Use the specified delimiter character (or "+strings.Join(delimiterNames(), ", ")+") instead of the comma")
+	flag.BoolVar(&CmdFlags.JSON, "json", false, "Use newline-delimited JSON output")
+	flag.BoolVar(&CmdFlags.JSONArray, "json-array", false, "Use JSON array output")
+	flag.BoolVar(&CmdFlags.Markdown, "markdown", false, "Use GitHub-flavored Markdown table output")
+	flag.StringVar(&CmdFlags.Template, "template", "", "Write each item with the specified Go template instead of the columns")
+	flag.StringVar(&CmdFlags.TemplateFile, "template-file", "", "Write each item with the Go template in the specified file instead of the columns")
+	flag.StringVar(&CmdFlags.DateFormat, "date-format", GoogleSheetDateFormat, "Go layout used to format dates (or \"rfc3339\")")
+	flag.StringVar(&CmdFlags.DatePreset, "date-preset", "", "Format the dates with a preset ("+strings.Join(DatePresetValues, ", ")+") instead of -date-format")
+	flag.StringVar(&CmdFlags.Fields, "fields", "", "Comma-separated fields written instead of the default columns (e.g. user,number,title)")
+	flag.BoolVar(&CmdFlags.LinkTitle, "link-title", false, "Write the title as a hyperlink to the item")
+	flag.BoolVar(&CmdFlags.NoHyperlink, "no-hyperlink", false, "Write plain values instead of hyperlinks and add a trailing URL column")
+	flag.BoolVar(&CmdFlags.Reactions, "reactions", false, "Include the total and thumbs-up reactions counts")
+	flag.BoolVar(&CmdFlags.LabelColors, "label-colors", false, "Include the color of the first label (e.g. for conditional formatting)")
+	flag.BoolVar(&CmdFlags.MilestoneDetails, "milestone-details", false, "Include the milestone due date")
+	flag.BoolVar(&CmdFlags.Age, "age", false, "Include the items age in days (until closed or now)")
+	flag.BoolVar(&CmdFlags.Branches, "branches", false, "Include the pull requests head and base branches (one request for each pull request)")
+	flag.BoolVar(&CmdFlags.Draft, "draft", false, "Include the pull requests draft status (one request for each pull request)")
+	flag.BoolVar(&CmdFlags.Commits, "commits", false, "Include the pull requests number of commits (one request for each pull request)")
+	flag.BoolVar(&CmdFlags.Reviews, "reviews", false, "Include the pull requests reviews and requested reviewers (two requests for each pull request)")
+	flag.BoolVar(&CmdFlags.RepoURL, "repo-url", false, "Include the repository URL")
+	flag.BoolVar(&CmdFlags.Participants, "participants", false, "Include the number of distinct authors of the item and of its comments (one request for each item with comments)")
+	flag.BoolVar(&CmdFlags.Body, "body", false, "Include the item body text")
+	flag.BoolVar(&CmdFlags.Header, "header", true, "Write a header row before the items")
+	flag.BoolVar(&CmdFlags.DryRun, "dry-run", false, "Print the number of items that would be dumped instead of the items")
+	flag.BoolVar(&CmdFlags.Summary, "summary", false, "Print item counts by type and author instead of the items")
+	flag.StringVar(&CmdFlags.Distribution, "distribution", "", "Print the counts and percentages of the values of the specified field instead of the items")
+	flag.StringVar(&CmdFlags.GroupBy, "group-by", "", "Count the items by the specified key with -summary ("+strings.Join(groupByValues(), ", ")+")")
+	flag.BoolVar(&CmdFlags.XLSX, "xlsx", false, "Use XLSX spreadsheet output (requires -out)")
+	flag.StringVar(&CmdFlags.SQLite, "sqlite", "", "Insert or replace the items in the specified SQLite database")
+	flag.StringVar(&CmdFlags.CommentsOutput, "comments-out", "", "Write the comments of the items to the specified CSV file (additional requests for each item)")
+	flag.BoolVar(&CmdFlags.Gzip, "gzip", false, "Compress the output with gzip (default with an -out file ending in "+GzipExtension+")")
+	flag.BoolVar(&CmdFlags.SplitByAuthor, "split-by-author", false, "Write the items of each author to a separate file in the -out-dir directory")
+	flag.StringVar(&CmdFlags.OutputDir, "out-dir", "", "Write the -split-by-author files to the specified directory")
+	flag.StringVar(&CmdFlags.Output, "out", "", "Write output to the specified file instead of stdout")
+	flag.IntVar(&CmdFlags.FlushEvery, "flush-every", 100, "Flush the output after the specified number of items (0 to flush only at the end)")
+	flag.BoolVar(&CmdFlags.Quiet, "quiet", false, "Do not log informational messages")
+	flag.BoolVar(&CmdFlags.Verbose, "verbose", false, "Log every API request")
+	flag.BoolVar(&CmdFlags.ShowRateLimit, "show-rate-limit", false, "Print the remaining API rate limits before and after the dump")
+	flag.BoolVar(&CmdFlags.Progress, "progress", false, "Print the dump progress to stderr")
 	flag.StringVar(&CmdFlags.Organization, "o", "golang", "GitHub owner/organization name")
-	flag.StringVar(&CmdFlags.Repository, "r", "go", "GitHub repository name")
-	flag.StringVar(&CmdFlags.Since, "s", since, "Retrieve items since specified date")
+	flag.StringVar(&CmdFlags.Repository, "r", "go", "Comma-separated GitHub repository names")
+	flag.StringVar(&CmdFlags.RepoFile, "repo-file", "", "Retrieve items from the owner/name repositories listed in the specified file instead of -o/-r")
+	flag.StringVar(&CmdFlags.Query, "query", "", "Retrieve the items matching the specified search query instead of the repository items")
+	flag.BoolVar(&CmdFlags.AllRepos, "all-repos", false, "Retrieve items from all the organization repositories")
+	flag.StringVar(&CmdFlags.OwnerType, "owner-type", "auto", "Owner type used to list the repositories with -all-repos ("+strings.Join(OwnerTypeValues, ", ")+")")
+	flag.BoolVar(&CmdFlags.ContinueOnError, "continue-on-error", false, "Skip the repositories that cannot be retrieved instead of stopping (exits with an error)")
+	flag.BoolVar(&CmdFlags.IncludeForks, "include-forks", false, "Include forked repositories when using -all-repos")
+	flag.BoolVar(&CmdFlags.IncludeArchived, "include-archived", false, "Include archived repositories when using -all-repos")
+	flag.StringVar(&CmdFlags.OutputOrder, "output-order", "fetch", "Order of the written items ("+strings.Join(OutputOrderValues, ", ")+"), by creation date the items are buffered in memory")
+	flag.StringVar(&CmdFlags.Direction, "direction", "desc", "Sort direction ("+strings.Join(DirectionValues, ", ")+")")
+	flag.StringVar(&CmdFlags.Sort, "sort", "created", "Sort the items by the specified field ("+strings.Join(SortValues, ", ")+")")
+	flag.StringVar(&CmdFlags.State, "state", "all", "Retrieve items in the specified state ("+strings.Join(StateValues, ", ")+")")
+	flag.StringVar(&CmdFlags.CacheDir, "cache-dir", "", "Cache the API responses in the specified directory and revalidate them")
+	flag.BoolVar(&CmdFlags.NoCache, "no-cache", false, "Do not use the -cache-dir responses cache")
+	flag.IntVar(&CmdFlags.Limit, "limit", 0, "Stop after the specified number of items (0 for no limit)")
+	flag.StringVar(&CmdFlags.ResumeFile, "resume-file", "", "Record the dump progress in the specified file and resume from it")
+	flag.StringVar(&CmdFlags.Since, "s", since, "Retrieve items since specified date (or relative, e.g. 7d, 2w, 3mo, 1y)")
+	flag.StringVar(&CmdFlags.SinceMilestone, "since-milestone", "", "Retrieve items since the creation of the specified milestone (title)")
+	flag.StringVar(&CmdFlags.APIURL, "api-url", "", "GitHub Enterprise API URL (e.g. https://github.example.com/api/v3)")
+	flag.BoolVar(&CmdFlags.ExcludePRs, "exclude-prs", false, "Do not retrieve pull requests")
+	flag.BoolVar(&CmdFlags.OnlyPRs, "only-prs", false, "Retrieve only pull requests")
+	flag.BoolVar(&CmdFlags.ExcludeDrafts, "exclude-drafts", false, "Do not retrieve draft pull requests (one request for each pull request)")
+	flag.BoolVar(&CmdFlags.MergedOnly, "merged-only", false, "Retrieve only merged pull requests")
+	flag.StringVar(&CmdFlags.WindowDate, "window-date", "created", "Date compared with the since/until window ("+strings.Join(WindowDateValues, ", ")+")")
+	flag.StringVar(&CmdFlags.WindowDate, "date-field", "created", "Alias of -window-date")
+	flag.IntVar(&CmdFlags.MinComments, "min-comments", 0, "Retrieve only items with at least the specified number of comments")
+	flag.StringVar(&CmdFlags.TitleContains, "title-contains", "", "Retrieve only items with the specified text in the title (case-insensitive)")
+	flag.BoolVar(&CmdFlags.ExcludeBots, "exclude-bots", false, "Do not retrieve items opened by bots")
+	flag.StringVar(&CmdFlags.ExcludeAuthors, "exclude-authors", "", "Do not retrieve items opened by the specified comma-separated users")
+	flag.StringVar(&CmdFlags.StateFile, "state-file", "", "Retrieve items since the last run recorded in the specified file (unless -s is set)")
+	flag.BoolVar(&CmdFlags.Pretty, "pretty", false, "Use aligned columns output for the terminal")
+	flag.IntVar(&CmdFlags.TitleWidth, "title-width", 60, "Truncate the -pretty output titles to the specified width (0 for no limit)")
+	flag.BoolVar(&CmdFlags.GraphQL, "graphql", false, "Retrieve the items with the GraphQL API (experimental)")
+	flag.IntVar(&CmdFlags.PerPage, "per-page", ghdump.MaxItemsPerPage, "Number of items retrieved with each request (1-100)")
+	flag.BoolVar(&CmdFlags.LinkedIssues, "linked-issues", false, "Include the issues closed by the pull requests (from their closes/fixes/resolves #N references)")
+	flag.BoolVar(&CmdFlags.BOM, "bom", false, "Write a UTF-8 byte order mark before the CSV output (for Excel)")
+	flag.IntVar(&CmdFlags.Project, "project", 0, "Retrieve the items of a project (classic) column, of the repository or of the organization with -all-repos")
+	flag.StringVar(&CmdFlags.ProjectColumn, "project-column", "", "Name of the -project column")
+	flag.BoolVar(&CmdFlags.NormalizeNewline, "normalize-newlines", false, "Replace the CR and CRLF line breaks of the titles and bodies with LF")
+	flag.BoolVar(&CmdFlags.EscapeNewlines, "escape-newlines", false, "Replace the line breaks of the titles and bodies with a literal \\n")
+	flag.BoolVar(&CmdFlags.Stats, "stats", false, "Print the number of items, the duration and the throughput of the dump")
+	flag.IntVar(&CmdFlags.FromNumber, "from-number", 0, "Retrieve only items numbered from the specified number")
+	flag.IntVar(&CmdFlags.ToNumber, "to-number", 0, "Retrieve only items numbered up to the specified number")
+	flag.BoolVar(&CmdFlags.NodeID, "node-id", false, "Include the GraphQL node ID, which is unique across repositories")
+	flag.StringVar(&CmdFlags.ClientCert, "client-cert", "", "TLS client certificate file (PEM) for the API requests, e.g. for a mutual TLS proxy")
+	flag.StringVar(&CmdFlags.ClientKey, "client-key", "", "TLS client certificate key file (PEM)")
+	flag.StringVar(&CmdFlags.Author, "author", "", "Retrieve only items opened by the specified user")
+	flag.StringVar(&CmdFlags.Assignee, "assignee", "", "Retrieve only items assigned to the specified user")
+	flag.StringVar(&CmdFlags.Milestone, "milestone", "", "Retrieve only items in the specified milestone (number or title, \"none\" or \"*\")")
+	flag.StringVar(&CmdFlags.Labels, "labels", "", "Retrieve only items with all the specified comma-separated labels")
+	flag.IntVar(&CmdFlags.Concurrency, "concurrency", 4, "Number of pages retrieved in parallel")
+	flag.IntVar(&CmdFlags.MaxRetries, "max-retries", 3, "Maximum number of retries on API rate limits and transient errors")
+	flag.DurationVar(&CmdFlags.Timeout, "timeout", 0, "Abort the dump after the specified duration (e.g. 30m)")
+	flag.StringVar(&CmdFlags.Until, "until", "", "Retrieve items until specified date (or relative), inclusive (default today)")
+}
+
+// dumpOptions returns the iteration options set from the flags, with the
+// progress and resume hooks of key (the repository or the search query).
+func dumpOptions(key string) *ghdump.Options {
+	return &ghdump.Options{
+		Owner:          CmdFlags.Organization,
+		OwnerType:      CmdFlags.OwnerType,
+		State:          CmdFlags.State,
+		Labels:         splitList(CmdFlags.Labels),
+		Assignee:       CmdFlags.Assignee,
+		Creator:        CmdFlags.Author,
+		Milestone:      CmdFlags.Milestone,
+		Sort:           CmdFlags.Sort,
+		Direction:      CmdFlags.Direction,
+		ExcludePRs:     CmdFlags.ExcludePRs,
+		OnlyPRs:        CmdFlags.OnlyPRs,
+		MinComments:    CmdFlags.MinComments,
+		TitleContains:  CmdFlags.TitleContains,
+		ExcludeBots:    CmdFlags.ExcludeBots,
+		ExcludeAuthors: splitList(CmdFlags.ExcludeAuthors),
+		FromNumber:     CmdFlags.FromNumber,
+		ToNumber:       CmdFlags.ToNumber,
+		Concurrency:    CmdFlags.Concurrency,
+		PerPage:        CmdFlags.PerPage,
+		MaxRetries:     CmdFlags.MaxRetries,
+		StartPage:      resume.StartPage(key),
+		PageDone: func(page int) error {
+			progress.Page(key, page)
+			return resume.PageDone(key, page)
+		},
+		Logf: logger.Infof,
+	}
+}
+
+type Item struct {
+	Repository    string         `json:"repository"`
+	RepositoryURL string         `json:"repository_url,omitempty"`
+	NodeID        string         `json:"node_id,omitempty"`
+	User          string         `json:"user"`
+	UserHTMLURL   string         `json:"user_html_url"`
+	Type          string         `json:"type"`
+	Number        int            `json:"number"`
+	HTMLURL       string         `json:"html_url"`
+	Title         string         `json:"title"`
+	State         string         `json:"state"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     *time.Time     `json:"updated_at"`
+	Labels        []string       `json:"labels"`
+	LabelColor    string         `json:"label_color,omitempty"`
+	Assignees     []string       `json:"assignees"`
+	Milestone     string         `json:"milestone"`
+	MilestoneDue  *time.Time     `json:"milestone_due_on,omitempty"`
+	ClosedAt      *time.Time     `json:"closed_at"`
+	MergedAt      *time.Time     `json:"merged_at,omitempty"`
+	Comments      int            `json:"comments"`
+	Participants  *int           `json:"participants,omitempty"`
+	Body          string         `json:"body,omitempty"`
+	Reactions     *ItemReactions `json:"reactions,omitempty"`
+	Age           *int           `json:"age,omitempty"`
+
+	Head    string `json:"head,omitempty"`
+	Base    string `json:"base,omitempty"`
+	Commits *int   `json:"commits,omitempty"`
+	Draft   *bool  `json:"draft,omitempty"`
+
+	Reviews            []string `json:"reviews,omitempty"`
+	RequestedReviewers []string `json:"requested_reviewers,omitempty"`
+	LinkedIssues       []int    `json:"linked_issues,omitempty"`
+
+	// The API objects are available to the -template templates
+	issue       *github.Issue
+	pullRequest *ghdump.PullRequest
+}
+
+type ItemReactions struct {
+	Total    int `json:"total"`
+	ThumbsUp int `json:"thumbs_up"`
+}
+
+func newItem(repository string, i *ghdump.Issue) *Item {
+	typeName := TypeIssue
+
+	if i.IsPullRequest() {
+		typeName = TypePullRequest
+	}
+
+	// Deleted accounts are returned without a user
+	user, userHTMLURL := UnknownUser, ""
+
+	if i.User != nil {
+		user, userHTMLURL = i.User.GetLogin(), i.User.GetHTMLURL()
+	}
+
+	labels, colors := []string{}, map[string]string{}
+
+	for _, l := range i.Labels {
+		labels = append(labels, l.GetName())
+		colors[l.GetName()] = l.GetColor()
+	}
+
+	sort.Strings(labels)
+
+	labelColor := ""
+
+	// The first label is the first one written in the labels column
+	if CmdFlags.LabelColors && len(labels) > 0 && len(colors[labels[0]]) > 0 {
+		labelColor = "#" + colors[labels[0]]
+	}
+
+	assignees := []string{}
+
+	for _, a := range i.Assignees {
+		assignees = append(assignees, a.GetLogin())
+	}
+
+	sort.Strings(assignees)
+
+	title, body := i.GetTitle(), ""
+
+	if CmdFlags.Body {
+		body = i.GetBody()
+	}
+
+	if CmdFlags.EscapeNewlines {
+		title, body = newlinesEscaper.Replace(title), newlinesEscaper.Replace(body)
+	} else if CmdFlags.NormalizeNewline {
+		title, body = newlinesNormalizer.Replace(title), newlinesNormalizer.Replace(body)
+	}
+
+	var reactions *ItemReactions
+
+	if CmdFlags.Reactions {
+		reactions = &ItemReactions{
+			Total:    i.GetReactions().GetTotalCount(),
+			ThumbsUp: i.GetReactions().GetPlusOne(),
+		}
+	}
+
+	nodeID := ""
+
+	// The objects cached before the node IDs were introduced lack them
+	if CmdFlags.NodeID && i.NodeID != nil {
+		nodeID = *i.NodeID
+	}
+
+	repositoryURL := ""
+
+	if CmdFlags.RepoURL {
+		repositoryURL = itemRepositoryURL(repository, &i.Issue)
+	}
+
+	var linkedIssues []int
+
+	if CmdFlags.LinkedIssues && i.IsPullRequest() {
+		linkedIssues = parseLinkedIssues(i.GetBody())
+	}
+
+	var milestoneDue *time.Time
+
+	if CmdFlags.MilestoneDetails && i.Milestone != nil {
+		milestoneDue = i.Milestone.DueOn
+	}
+
+	var age *int
+
+	// The open items are still aging, the closed ones lived until closed
+	if CmdFlags.Age {
+		end := time.Now()
+
+		if i.ClosedAt != nil {
+			end = *i.ClosedAt
+		}
+
+		days := int(end.Sub(i.GetCreatedAt()).Round(24*time.Hour) / (24 * time.Hour))
+		age = &days
+	}
+
+	state := i.GetState()
+
+	var mergedAt *time.Time
+
+	// The closed pull requests are distinguished from the merged ones
+	if state == "closed" && i.MergedAt != nil {
+		state, mergedAt = "merged", i.MergedAt
+	}
+
+	return &Item{
+		Repository:    repository,
+		RepositoryURL: repositoryURL,
+		NodeID:        nodeID,
+		User:          user,
+		UserHTMLURL:   userHTMLURL,
+		Type:          typeName,
+		Number:        *i.Number,
+		HTMLURL:       *i.HTMLURL,
+		Title:         title,
+		State:         state,
+		CreatedAt:     *i.CreatedAt,
+		UpdatedAt:     i.UpdatedAt,
+		Labels:        labels,
+		LabelColor:    labelColor,
+		Assignees:     assignees,
+		Milestone:     i.GetMilestone().GetTitle(),
+		MilestoneDue:  milestoneDue,
+		ClosedAt:      i.ClosedAt,
+		MergedAt:      mergedAt,
+		Comments:      i.GetComments(),
+		Body:          body,
+		Reactions:     reactions,
+		Age:           age,
+		LinkedIssues:  linkedIssues,
+		issue:         &i.Issue,
+	}
+}
+
+// parseLinkedIssues returns the distinct issue numbers referenced by the
+// closing keywords of body, in order of appearance.
+func parseLinkedIssues(body string) []int {
+	numbers, seen := []int{}, map[int]bool{}
+
+	for _, m := range LinkedIssuesRegexp.FindAllStringSubmatch(body, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || seen[n] {
+			continue
+		}
+
+		numbers, seen[n] = append(numbers, n), true
+	}
+
+	return numbers
+}
+
+// repositoryURLs maps the dumped owner/name repositories to their HTML URL
+var repositoryURLs = map[string]string{}
+
+// itemRepositoryURL returns the HTML URL of the item repository, the search
+// results repositories are not retrieved and the URL is derived from the item
+// one instead.
+func itemRepositoryURL(repository string, i *github.Issue) string {
+	if u, ok := repositoryURLs[repository]; ok {
+		return u
+	}
+
+	u := i.GetHTMLURL()
+
+	for _, sep := range []string{"/issues/", "/pull/"} {
+		if n := strings.LastIndex(u, sep); n >= 0 {
+			return u[:n]
+		}
+	}
+
+	return ""
+}
+
+func templateOutput() bool {
+	return len(CmdFlags.Template) > 0 || len(CmdFlags.TemplateFile) > 0
+}
+
+// setWindow sets the since/until window of opts, with the other -window-date
+// values the items are compared by that date in skipItem instead. The items
+// closed or merged after since were also updated after it.
+func setWindow(opts *ghdump.Options, since, until time.Time) {
+	if CmdFlags.WindowDate != "created" {
+		opts.UpdatedSince = since
+		return
+	}
+
+	opts.Since, opts.Until = since, until
+}
+
+// windowDate returns the item date compared with the -window-date window
+func windowDate(item *Item) *time.Time {
+	switch CmdFlags.WindowDate {
+	case "closed":
+		return item.ClosedAt
+	case "updated":
+		return item.UpdatedAt
+	case "merged":
+		return item.MergedAt
+	}
+
+	return &item.CreatedAt
 }
 
-func iterateIssues(client *github.Client, since time.Time, fn func(*github.Issue) error) error {
-	options := github.IssueListByRepoOptions{
-		Direction:   "desc",
-		Sort:        "created",
-		State:       "all",
-		ListOptions: github.ListOptions{PerPage: GitHubMaxItemsPerPage},
+// addParticipants counts the distinct authors of the item and its comments,
+// the deleted accounts are not counted. The logins are case-insensitive.
+func addParticipants(ctx context.Context, client *github.Client, item *Item) error {
+	participants := map[string]bool{}
+
+	if item.User != UnknownUser {
+		participants[strings.ToLower(item.User)] = true
 	}
 
-	for {
-		issues, response, err := client.Issues.ListByRepo(context.Background(), CmdFlags.Organization, CmdFlags.Repository, &options)
+	if item.Comments > 0 {
+		opts := dumpOptions("")
+		opts.Owner, opts.Repository = splitRepository(item.Repository)
+
+		err := ghdump.IterateComments(ctx, client, opts, item.Number, func(c *github.IssueComment) error {
+			if c.User != nil {
+				participants[strings.ToLower(c.User.GetLogin())] = true
+			}
+			return nil
+		})
 		if err != nil {
 			return err
 		}
+	}
 
-		for _, i := range issues {
-			if i.CreatedAt.Before(since) {
-				return nil
-			}
+	count := len(participants)
+	item.Participants = &count
 
-			err := fn(i)
-			if err != nil {
-				return err
-			}
+	return nil
+}
+
+// skipItem applies the filters that require the pull request data
+func skipItem(item *Item, since, until time.Time) bool {
+	if CmdFlags.ExcludeDrafts && item.Draft != nil && *item.Draft {
+		return true
+	}
+
+	if CmdFlags.MergedOnly && item.MergedAt == nil {
+		return true
+	}
+
+	if CmdFlags.WindowDate != "created" {
+		date := windowDate(item)
+
+		if date == nil || date.Before(since) || !date.Before(until) {
+			return true
 		}
+	}
 
-		if response.NextPage == 0 {
-			break
+	return false
+}
+
+// latestReviews returns the latest state of the reviews of each reviewer,
+// the comments are superseded by approvals and change requests.
+func latestReviews(reviews []*github.PullRequestReview) []string {
+	states := map[string]string{}
+
+	for _, r := range reviews {
+		login, state := r.GetUser().GetLogin(), r.GetState()
+
+		if state == "PENDING" || (state == "COMMENTED" && len(states[login]) > 0) {
+			continue
+		}
+
+		states[login] = state
+	}
+
+	latest := []string{}
+
+	for login, state := range states {
+		latest = append(latest, login+":"+state)
+	}
+
+	sort.Strings(latest)
+
+	return latest
+}
+
+// addPullRequestData adds the pull request data that is not returned by the
+// issues endpoint, the pull request is retrieved only when needed.
+func addPullRequestData(ctx context.Context, client *github.Client, item *Item) error {
+	if item.Type != TypePullRequest || (!CmdFlags.Reviews && !CmdFlags.Branches && !CmdFlags.Commits && !CmdFlags.Draft && !CmdFlags.ExcludeDrafts && !templateOutput()) {
+		return nil
+	}
+
+	opts := dumpOptions("")
+	opts.Owner, opts.Repository = splitRepository(item.Repository)
+
+	pr, err := ghdump.GetPullRequest(ctx, client, opts, item.Number)
+	if err != nil {
+		return err
+	}
+
+	item.pullRequest = pr
+
+	// The branches of deleted forks can be partially populated
+	if CmdFlags.Branches {
+		item.Head, item.Base = pr.GetHead().GetRef(), pr.GetBase().GetRef()
+	}
+
+	// The number of commits is not part of the issues listing
+	if CmdFlags.Commits {
+		item.Commits = pr.Commits
+	}
+
+	if CmdFlags.Draft || CmdFlags.ExcludeDrafts {
+		draft := pr.GetDraft()
+		item.Draft = &draft
+	}
+
+	if CmdFlags.Reviews {
+		reviews, err := ghdump.ListReviews(ctx, client, opts, item.Number)
+		if err != nil {
+			return err
+		}
+
+		item.Reviews = latestReviews(reviews)
+		item.RequestedReviewers = []string{}
+
+		for _, u := range pr.RequestedReviewers {
+			item.RequestedReviewers = append(item.RequestedReviewers, u.GetLogin())
 		}
 
-		options.Page = response.NextPage
+		sort.Strings(item.RequestedReviewers)
 	}
 
 	return nil
 }
 
-func googleSheetHyperlink(value interface{}, link string) string {
-	return fmt.Sprintf("=HYPERLINK(\"%s\", \"%v\")", link, value)
+type Column struct {
+	Field string
+	Name  string
+	Value func(*Item) string
+	Link  func(*Item) string
 }
 
-func gitHubHTTPClient() *http.Client {
-	token := os.Getenv(GitHubTokenEnvVarName)
+var ItemColumns = []Column{
+	{"repository", "Repository", func(i *Item) string { return i.Repository }, nil},
+	{"user", "User", func(i *Item) string { return i.User }, func(i *Item) string { return i.UserHTMLURL }},
+	{"type", "Type", func(i *Item) string { return i.Type }, nil},
+	{"number", "Number", func(i *Item) string { return strconv.Itoa(i.Number) }, func(i *Item) string { return i.HTMLURL }},
+	{"title", "Title", func(i *Item) string { return i.Title }, nil},
+	{"state", "State", func(i *Item) string { return i.State }, nil},
+	{"created_at", "CreatedAt", func(i *Item) string { return formatTime(&i.CreatedAt) }, nil},
+	{"updated_at", "UpdatedAt", func(i *Item) string { return formatTime(i.UpdatedAt) }, nil},
+	{"labels", "Labels", func(i *Item) string { return strings.Join(i.Labels, ListSeparator) }, nil},
+	{"assignees", "Assignees", func(i *Item) string { return strings.Join(i.Assignees, ListSeparator) }, nil},
+	{"milestone", "Milestone", func(i *Item) string { return i.Milestone }, nil},
+	{"closed_at", "ClosedAt", func(i *Item) string { return formatTime(i.ClosedAt) }, nil},
+	{"comments", "Comments", func(i *Item) string { return strconv.Itoa(i.Comments) }, nil},
+}
 
-	if len(token) > 0 {
-		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-		return oauth2.NewClient(context.Background(), ts)
+var ReactionsColumns = []Column{
+	{"reactions", "Reactions", func(i *Item) string { return strconv.Itoa(i.Reactions.Total) }, nil},
+	{"thumbs_up", "ThumbsUp", func(i *Item) string { return strconv.Itoa(i.Reactions.ThumbsUp) }, nil},
+}
+
+var LabelColorColumn = Column{"label_color", "LabelColor", func(i *Item) string { return i.LabelColor }, nil}
+
+var MilestoneDueColumn = Column{"milestone_due_on", "MilestoneDueOn", func(i *Item) string { return formatTime(i.MilestoneDue) }, nil}
+
+var AgeColumn = Column{"age", "Age", func(i *Item) string { return strconv.Itoa(*i.Age) }, nil}
+
+var BranchesColumns = []Column{
+	{"head", "Head", func(i *Item) string { return i.Head }, nil},
+	{"base", "Base", func(i *Item) string { return i.Base }, nil},
+}
+
+var CommitsColumn = Column{"commits", "Commits", func(i *Item) string {
+	if i.Commits == nil {
+		return ""
 	}
+	return strconv.Itoa(*i.Commits)
+}, nil}
 
-	if len(CmdFlags.Username) > 0 && len(CmdFlags.Password) > 0 {
-		ts := &http.Client{
-			Transport: &github.BasicAuthTransport{
-				Transport: &http.Transport{},
-				Username:  CmdFlags.Username,
-				Password:  CmdFlags.Password,
-			},
+var DraftColumn = Column{"draft", "Draft", func(i *Item) string {
+	if i.Draft == nil {
+		return ""
+	}
+	return strconv.FormatBool(*i.Draft)
+}, nil}
+
+var ReviewsColumns = []Column{
+	{"reviews", "Reviews", func(i *Item) string { return strings.Join(i.Reviews, ListSeparator) }, nil},
+	{"requested_reviewers", "RequestedReviewers", func(i *Item) string { return strings.Join(i.RequestedReviewers, ListSeparator) }, nil},
+}
+
+var LinkedIssuesColumn = Column{"linked_issues", "LinkedIssues", func(i *Item) string {
+	numbers := make([]string, len(i.LinkedIssues))
+	for n, l := range i.LinkedIssues {
+		numbers[n] = strconv.Itoa(l)
+	}
+	return strings.Join(numbers, ListSeparator)
+}, nil}
+
+var RepositoryURLColumn = Column{"repository_url", "RepositoryURL", func(i *Item) string { return i.RepositoryURL }, func(i *Item) string { return i.RepositoryURL }}
+
+var ParticipantsColumn = Column{"participants", "Participants", func(i *Item) string { return strconv.Itoa(*i.Participants) }, nil}
+
+var NodeIDColumn = Column{"node_id", "NodeID", func(i *Item) string { return i.NodeID }, nil}
+
+var BodyColumn = Column{"body", "Body", func(i *Item) string { return i.Body }, nil}
+var URLColumn = Column{"url", "URL", func(i *Item) string { return i.HTMLURL }, nil}
+
+// FieldColumns maps the -fields names to all the available columns
+var FieldColumns = map[string]Column{}
+
+func init() {
+	columns := append([]Column{}, ItemColumns...)
+	columns = append(columns, ReactionsColumns...)
+	columns = append(columns, LabelColorColumn, MilestoneDueColumn, AgeColumn)
+	columns = append(columns, BranchesColumns...)
+	columns = append(columns, CommitsColumn, DraftColumn)
+	columns = append(columns, ReviewsColumns...)
+	columns = append(columns, LinkedIssuesColumn)
+	columns = append(columns, ParticipantsColumn, RepositoryURLColumn, NodeIDColumn, BodyColumn, URLColumn)
+
+	for _, c := range columns {
+		FieldColumns[c.Field] = c
+	}
+}
+
+func fieldNames() []string {
+	names := []string{}
+
+	for name := range FieldColumns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// fieldColumns returns the columns of the comma-separated fields
+func fieldColumns(fields string) ([]Column, error) {
+	columns := []Column{}
+
+	for _, f := range splitList(fields) {
+		c, ok := FieldColumns[f]
+		if !ok {
+			return nil, fmt.Errorf("Invalid field %q: valid fields are %s", f, strings.Join(fieldNames(), ", "))
 		}
-		return ts
+
+		columns = append(columns, c)
 	}
 
-	return nil
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("No fields specified: valid fields are %s", strings.Join(fieldNames(), ", "))
+	}
+
+	return columns, nil
 }
 
-func main() {
-	flag.Parse()
+// selectColumns sets the flags retrieving the optional values of columns
+func selectColumns(columns []Column) {
+	for _, c := range columns {
+		switch c.Field {
+		case "reactions", "thumbs_up":
+			CmdFlags.Reactions = true
+		case "label_color":
+			CmdFlags.LabelColors = true
+		case "milestone_due_on":
+			CmdFlags.MilestoneDetails = true
+		case "age":
+			CmdFlags.Age = true
+		case "head", "base":
+			CmdFlags.Branches = true
+		case "commits":
+			CmdFlags.Commits = true
+		case "draft":
+			CmdFlags.Draft = true
+		case "reviews", "requested_reviewers":
+			CmdFlags.Reviews = true
+		case "linked_issues":
+			CmdFlags.LinkedIssues = true
+		case "participants":
+			CmdFlags.Participants = true
+		case "repository_url":
+			CmdFlags.RepoURL = true
+		case "node_id":
+			CmdFlags.NodeID = true
+		case "body":
+			CmdFlags.Body = true
+		}
+	}
+}
 
-	CmdFlags.Password = os.Getenv(GitHubPasswordEnvVarName)
+// linkColumns sets the links of the columns selected by the flags
+func linkColumns(columns []Column) []Column {
+	for n, c := range columns {
+		if c.Field == "title" && CmdFlags.LinkTitle {
+			columns[n].Link = func(i *Item) string { return i.HTMLURL }
+		}
+	}
+
+	return columns
+}
 
-	httpClient := gitHubHTTPClient()
-	if httpClient == nil && CmdFlags.NoLogin == false {
-		log.Fatal("No authentication could trigger API rate limiting: use authentication or use the flag -n to force.")
+func outputColumns() []Column {
+	if len(CmdFlags.Fields) > 0 {
+		// The fields were validated in main
+		columns, _ := fieldColumns(CmdFlags.Fields)
+		return linkColumns(columns)
 	}
 
-	ghClient := github.NewClient(httpClient)
+	columns := append([]Column{}, ItemColumns...)
 
-	w := csv.NewWriter(os.Stdout)
-	defer w.Flush()
+	if CmdFlags.Reactions {
+		columns = append(columns, ReactionsColumns...)
+	}
 
-	if CmdFlags.TabSeparated {
-		w.Comma = '\t'
+	if CmdFlags.LabelColors {
+		columns = append(columns, LabelColorColumn)
 	}
 
-	sinceDateTime, err := time.Parse(CmdFlagsSinceFormat, CmdFlags.Since)
-	if err != nil {
-		log.Fatal(err)
+	if CmdFlags.MilestoneDetails {
+		columns = append(columns, MilestoneDueColumn)
+	}
+
+	if CmdFlags.Age {
+		columns = append(columns, AgeColumn)
+	}
+
+	if CmdFlags.Branches {
+		columns = append(columns, BranchesColumns...)
+	}
+
+	if CmdFlags.Commits {
+		columns = append(columns, CommitsColumn)
+	}
+
+	if CmdFlags.Draft {
+		columns = append(columns, DraftColumn)
+	}
+
+	if CmdFlags.Reviews {
+		columns = append(columns, ReviewsColumns...)
+	}
+
+	if CmdFlags.LinkedIssues {
+		columns = append(columns, LinkedIssuesColumn)
+	}
+
+	if CmdFlags.Participants {
+		columns = append(columns, ParticipantsColumn)
+	}
+
+	if CmdFlags.RepoURL {
+		columns = append(columns, RepositoryURLColumn)
+	}
+
+	if CmdFlags.NodeID {
+		columns = append(columns, NodeIDColumn)
+	}
+
+	if CmdFlags.Body {
+		columns = append(columns, BodyColumn)
+	}
+
+	if CmdFlags.NoHyperlink {
+		columns = append(columns, URLColumn)
 	}
 
-	err = iterateIssues(ghClient, sinceDateTime, func(i *github.Issue) error {
-		typeName := TypeIssue
+	return linkColumns(columns)
+}
+
+// itemRow returns the values of the item columns, the linked values are
+// formatted with hyperlink unless it is nil or the link is empty.
+func itemRow(columns []Column, item *Item, hyperlink func(value, link string) string) []string {
+	row := make([]string, len(columns))
+
+	for n, c := range columns {
+		row[n] = c.Value(item)
 
-		if i.IsPullRequest() {
-			typeName = TypePullRequest
+		if hyperlink != nil && c.Link != nil {
+			if link := c.Link(item); len(link) > 0 {
+				row[n] = hyperlink(row[n], link)
+			}
 		}
+	}
 
-		err := w.Write([]string{
-			googleSheetHyperlink(*i.User.Login, *i.User.HTMLURL),
-			typeName,
-			googleSheetHyperlink(*i.Number, *i.HTMLURL),
-			*i.Title,
-			i.CreatedAt.Format(GoogleSheetDateFormat),
-		})
+	return row
+}
 
-		return err
-	})
+type ItemWriter interface {
+	WriteHeader() error
+	Write(*Item) error
+	Flush() error
+	Close() error
+}
 
-	if err != nil {
-		log.Fatal(err)
+type csvItemWriter struct {
+	w          *csv.Writer
+	columns    []Column
+	hyperlinks bool
+}
+
+func newCSVItemWriter(w io.Writer, columns []Column, comma rune, hyperlinks bool) *csvItemWriter {
+	c := csv.NewWriter(w)
+	c.Comma = comma
+
+	return &csvItemWriter{c, columns, hyperlinks}
+}
+
+func (c *csvItemWriter) WriteHeader() error {
+	header := make([]string, len(c.columns))
+
+	for n, col := range c.columns {
+		header[n] = col.Name
+	}
+
+	return c.w.Write(header)
+}
+
+func (c *csvItemWriter) Write(item *Item) error {
+	var hyperlink func(string, string) string
+
+	if c.hyperlinks {
+		hyperlink = googleSheetHyperlink
 	}
+
+	return c.w.Write(itemRow(c.columns, item, hyperlink))
+}
+
+func (c *csvItemWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvItemWriter) Close() error {
+	return c.Flush()
+}
+
+type jsonItemWriter struct {
+	e *json.Encoder
+}
+
+func newJSONItemWriter(w io.Writer) *jsonItemWriter {
+	return &jsonItemWriter{json.NewEncoder(w)}
+}
+
+func (j *jsonItemWriter) WriteHeader() error {
+	return nil
+}
+
+func (j *jsonItemWriter) Write(item *Item) error {
+	return j.e.Encode(item)
+}
+
+func (j *jsonItemWriter) Flush() error {
+	return nil
+}
+
+func (j *jsonItemWriter) Close() error {
+	return nil
+}
+
+// jsonArrayItemWriter streams the items as the elements of a JSON array
+type jsonArrayItemWriter struct {
+	w       *bufio.Writer
+	written bool
+}
+
+func newJSONArrayItemWriter(w io.Writer) *jsonArrayItemWriter {
+	return &jsonArrayItemWriter{bufio.NewWriter(w), false}
+}
+
+func (j *jsonArrayItemWriter) WriteHeader() error {
+	return nil
+}
+
+func (j *jsonArrayItemWriter) Write(item *Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+
+	if !j.written {
+		separator = "[\n"
+	}
+
+	_, err = j.w.WriteString(separator)
+	if err != nil {
+		return err
+	}
+
+	j.written = true
+
+	_, err = j.w.Write(data)
+
+	return err
+}
+
+func (j *jsonArrayItemWriter) Flush() error {
+	return j.w.Flush()
+}
+
+func (j *jsonArrayItemWriter) Close() error {
+	end := "\n]\n"
+
+	if !j.written {
+		end = "[]\n"
+	}
+
+	_, err := j.w.WriteString(end)
+	if err != nil {
+		return err
+	}
+
+	return j.Flush()
+}
+
+// splitRepository splits an owner/name repository
+func splitRepository(repository string) (string, string) {
+	parts := strings.SplitN(repository, "/", 2)
+
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+func splitList(value string) []string {
+	list := []string{}
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+
+		if len(v) > 0 {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+// DelimiterNames are the -delimiter names of the common delimiters
+var DelimiterNames = map[string]rune{"comma": ',', "tab": '\t', "pipe": '|', "semicolon": ';'}
+
+func delimiterNames() []string {
+	names := []string{}
+
+	for name := range DelimiterNames {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// parseDelimiter returns the delimiter name or single character value, the
+// quotes and the line breaks cannot be used as delimiters
+func parseDelimiter(value string) (rune, error) {
+	if d, ok := DelimiterNames[value]; ok {
+		return d, nil
+	}
+
+	d, size := utf8.DecodeRuneInString(value)
+
+	if size == 0 || size != len(value) || d == utf8.RuneError || d == '"' || d == '\r' || d == '\n' {
+		return 0, fmt.Errorf("Invalid delimiter %q: a single character or one of %s is required", value, strings.Join(delimiterNames(), ", "))
+	}
+
+	return d, nil
+}
+
+func validateFlagValue(name, value string, valid []string) error {
+	for _, v := range valid {
+		if value == v {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Invalid value %q for flag -%s: valid values are %s", value, name, strings.Join(valid, ", "))
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(CmdFlags.DateFormat)
+}
+
+// parseDate parses an absolute date or a date relative to now (days, weeks,
+// months or years ago), the relative dates are truncated to the day as well.
+func parseDate(value string, now time.Time) (time.Time, error) {
+	m := RelativeDateRegexp.FindStringSubmatch(value)
+
+	if m == nil {
+		t, err := time.Parse(CmdFlagsSinceFormat, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Invalid date %q: use the %s format or a relative date (e.g. 7d, 2w, 3mo, 1y)", value, CmdFlagsSinceFormat)
+		}
+
+		return t, nil
+	}
+
+	n, _ := strconv.Atoi(m[1])
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch m[2] {
+	case "w":
+		return today.AddDate(0, 0, -7*n), nil
+	case "mo":
+		return today.AddDate(0, -n, 0), nil
+	case "y":
+		return today.AddDate(-n, 0, 0), nil
+	}
+
+	return today.AddDate(0, 0, -n), nil
+}
+
+func validateDateFormat(layout string) error {
+	known := time.Date(2009, time.November, 10, 23, 4, 5, 0, time.UTC)
+	value := known.Format(layout)
+
+	parsed, err := time.Parse(layout, value)
+	if err != nil || value == layout || parsed.Format(layout) != value {
+		return fmt.Errorf("Invalid date format %q", layout)
+	}
+
+	return nil
+}
+
+// googleSheetHyperlink doubles the quotes of the formula string literals
+func googleSheetHyperlink(value, link string) string {
+	quote := func(v string) string { return strings.Replace(v, "\"", "\"\"", -1) }
+	return fmt.Sprintf("=HYPERLINK(\"%s\", \"%s\")", quote(link), quote(value))
+}
+
+func gitHubToken() (string, error) {
+	if len(CmdFlags.TokenFile) == 0 {
+		for _, name := range GitHubTokenEnvVarNames {
+			if token := os.Getenv(name); len(token) > 0 {
+				return token, nil
+			}
+		}
+
+		return "", nil
+	}
+
+	data, err := os.ReadFile(CmdFlags.TokenFile)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(data))
+
+	if len(token) == 0 {
+		return "", fmt.Errorf("Token file %s is empty", CmdFlags.TokenFile)
+	}
+
+	return token, nil
+}
+
+// gitHubTransport returns the transport used for the API requests, the
+// authentication is added on top of it.
+func gitHubTransport() (http.RoundTripper, error) {
+	var transport http.RoundTripper = http.DefaultTransport
+
+	if len(CmdFlags.ClientCert) > 0 || len(CmdFlags.ClientKey) > 0 {
+		if len(CmdFlags.ClientCert) == 0 || len(CmdFlags.ClientKey) == 0 {
+			return nil, fmt.Errorf("The TLS client authentication requires both -client-cert and -client-key")
+		}
+
+		cert, err := tls.LoadX509KeyPair(CmdFlags.ClientCert, CmdFlags.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot load the client certificate %s and key %s: %v", CmdFlags.ClientCert, CmdFlags.ClientKey, err)
+		}
+
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+
+		transport = t
+	}
+
+	if len(CmdFlags.CacheDir) == 0 || CmdFlags.NoCache {
+		return transport, nil
+	}
+
+	return newCacheTransport(CmdFlags.CacheDir, transport)
+}
+
+func gitHubHTTPClient(transport http.RoundTripper) (*http.Client, error) {
+	token, err := gitHubToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+
+	if len(token) > 0 {
+		tokenAuthentication = true
+
+		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+		return oauth2.NewClient(ctx, ts), nil
+	}
+
+	if CmdFlags.AppID > 0 || CmdFlags.InstallationID > 0 || len(CmdFlags.AppKeyFile) > 0 {
+		if CmdFlags.AppID == 0 || CmdFlags.InstallationID == 0 || len(CmdFlags.AppKeyFile) == 0 {
+			return nil, fmt.Errorf("GitHub App authentication requires -app-id, -installation-id and -app-key-file")
+		}
+
+		ts, err := newAppTokenSource(CmdFlags.AppID, CmdFlags.InstallationID, CmdFlags.AppKeyFile, transport)
+		if err != nil {
+			return nil, err
+		}
+
+		// Installation tokens expire after an hour and are then renewed
+		return oauth2.NewClient(ctx, oauth2.ReuseTokenSource(nil, ts)), nil
+	}
+
+	if len(CmdFlags.Username) == 0 && len(CmdFlags.Password) == 0 {
+		host := GitHubAPIHost
+
+		if apiURL, err := url.Parse(CmdFlags.APIURL); err == nil && len(apiURL.Host) > 0 {
+			host = apiURL.Hostname()
+		}
+
+		CmdFlags.Username, CmdFlags.Password, err = netrcCredentials(host)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(CmdFlags.Username) > 0 && len(CmdFlags.Password) > 0 {
+		ts := &http.Client{
+			Transport: &github.BasicAuthTransport{
+				Transport: transport,
+				Username:  CmdFlags.Username,
+				Password:  CmdFlags.Password,
+				OTP:       CmdFlags.OTP,
+			},
+		}
+		return ts, nil
+	}
+
+	return nil, nil
+}
+
+func newGitHubClient(httpClient *http.Client) (*github.Client, error) {
+	if len(CmdFlags.APIURL) == 0 {
+		return github.NewClient(httpClient), nil
+	}
+
+	apiURL, err := url.Parse(CmdFlags.APIURL)
+	if err != nil || (apiURL.Scheme != "http" && apiURL.Scheme != "https") || len(apiURL.Host) == 0 {
+		return nil, fmt.Errorf("Invalid GitHub API URL %q: an absolute http(s) URL is required", CmdFlags.APIURL)
+	}
+
+	// Uploads are never performed, the API URL is used for both endpoints
+	return github.NewEnterpriseClient(apiURL.String(), apiURL.String(), httpClient)
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*github.ErrorResponse)
+	return ok && e.Response.StatusCode == http.StatusNotFound
+}
+
+// tokenAuthentication reports whether a personal access token is used
+var tokenAuthentication = false
+
+// tokenAccessError returns an error describing the missing access of the
+// token to repo when err is a not found or forbidden response: the
+// repositories that a token cannot access are reported as not found.
+func tokenAccessError(repo string, err error) error {
+	e, ok := err.(*github.ErrorResponse)
+	if !ok || !tokenAuthentication || (e.Response.StatusCode != http.StatusNotFound && e.Response.StatusCode != http.StatusForbidden) {
+		return nil
+	}
+
+	// The fine-grained tokens have no scopes, only the selected repositories
+	scopes := "none reported, as for the fine-grained tokens"
+
+	if values, ok := e.Response.Header[http.CanonicalHeaderKey(GitHubOAuthScopesHeader)]; ok {
+		scopes = strings.Join(values, ", ")
+
+		if len(strings.TrimSpace(scopes)) == 0 {
+			scopes = "none"
+		}
+	}
+
+	return fmt.Errorf("Repository %s not found or the token lacks access to it (token scopes: %s)", repo, scopes)
+}
+
+// validateRepositories checks that the organization (with -all-repos) or the
+// owner/name repositories exist before starting the dump, and returns the
+// repositories to dump. The repositories failing the check are left out when
+// skip reports that the dump can continue without them.
+func validateRepositories(ctx context.Context, client *github.Client, repos []string, skip func(string, error) bool) ([]string, error) {
+	opts := dumpOptions("")
+
+	if CmdFlags.AllRepos {
+		ownerType, err := ghdump.ResolveOwnerType(ctx, client, opts)
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%s %s not found", OwnerTypeNames[CmdFlags.OwnerType], CmdFlags.Organization)
+		}
+
+		CmdFlags.OwnerType = ownerType
+
+		return repos, err
+	}
+
+	valid := []string{}
+
+	for _, repo := range repos {
+		opts.Owner, opts.Repository = splitRepository(repo)
+
+		r, err := ghdump.GetRepository(ctx, client, opts)
+		if accessErr := tokenAccessError(repo, err); accessErr != nil {
+			err = accessErr
+		} else if isNotFound(err) {
+			err = fmt.Errorf("Repository %s not found", repo)
+		}
+
+		if err != nil && skip(repo, err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		repositoryURLs[repo] = r.GetHTMLURL()
+		valid = append(valid, repo)
+	}
+
+	return valid, nil
+}
+
+// showRateLimits prints the remaining core rate limit, and the search one
+// with -query, regardless of the log level.
+func showRateLimits(ctx context.Context, client *github.Client, when string) {
+	limits, err := ghdump.GetRateLimits(ctx, client, dumpOptions(""))
+	if err != nil {
+		log.Printf("Cannot retrieve the rate limits: %v", err)
+		return
+	}
+
+	printRate := func(name string, rate *github.Rate) {
+		log.Printf("Rate limit %s the dump (%s): %d of %d remaining, reset at %s", when, name, rate.Remaining, rate.Limit, rate.Reset.Format(time.RFC3339))
+	}
+
+	printRate("core", limits.GetCore())
+
+	if len(CmdFlags.Query) > 0 {
+		printRate("search", limits.GetSearch())
+	}
+}
+
+func fatalError(ctx context.Context, err error) {
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Fatalf("Timeout of %s exceeded", CmdFlags.Timeout)
+	}
+
+	if ctx.Err() == context.Canceled {
+		log.Fatal("Interrupted")
+	}
+
+	if _, ok := err.(*github.TwoFactorAuthError); ok {
+		log.Fatalf("Two-factor authentication required: use -otp or %s to supply the one-time password", GitHubOTPEnvVarName)
+	}
+
+	log.Fatal(err)
+}
+
+func main() {
+	start := time.Now()
+
+	flag.Parse()
+
+	if len(CmdFlags.Config) > 0 {
+		err := loadConfig(CmdFlags.Config)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if CmdFlags.ExpandEnv {
+		expandEnvFlags()
+	}
+
+	CmdFlags.Password = os.Getenv(GitHubPasswordEnvVarName)
+
+	if len(CmdFlags.OTP) == 0 {
+		CmdFlags.OTP = os.Getenv(GitHubOTPEnvVarName)
+	}
+
+	if CmdFlags.Quiet && CmdFlags.Verbose {
+		log.Fatal("The -quiet and -verbose flags cannot be used together")
+	}
+
+	if CmdFlags.Quiet {
+		logger.level = LogLevelQuiet
+	}
+
+	if CmdFlags.Verbose {
+		logger.level = LogLevelDebug
+	}
+
+	err := validateFlagValue("state", CmdFlags.State, StateValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = validateFlagValue("sort", CmdFlags.Sort, SortValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = validateFlagValue("direction", CmdFlags.Direction, DirectionValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = validateFlagValue("owner-type", CmdFlags.OwnerType, OwnerTypeValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = validateFlagValue("output-order", CmdFlags.OutputOrder, OutputOrderValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if CmdFlags.OutputOrder != "fetch" && len(CmdFlags.ResumeFile) > 0 {
+		log.Fatal("The -output-order flag cannot be used with -resume-file")
+	}
+
+	err = validateFlagValue("window-date", CmdFlags.WindowDate, WindowDateValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(CmdFlags.Query) > 0 && (CmdFlags.AllRepos || len(CmdFlags.Labels) > 0 || len(CmdFlags.Assignee) > 0 || len(CmdFlags.Author) > 0 || len(CmdFlags.Milestone) > 0 || CmdFlags.State != "all") {
+		log.Fatal("The -query flag cannot be used with -all-repos, -labels, -assignee, -author, -milestone or -state: use the search qualifiers instead")
+	}
+
+	// Only the merged pull requests have a merge date
+	if CmdFlags.WindowDate == "merged" {
+		CmdFlags.MergedOnly = true
+	}
+
+	// Only the closed items have a closing date
+	if CmdFlags.WindowDate == "closed" {
+		if CmdFlags.State == "open" {
+			log.Fatal("The -window-date closed flag cannot be used with -state open")
+		}
+
+		CmdFlags.State = "closed"
+	}
+
+	if CmdFlags.MergedOnly {
+		if CmdFlags.ExcludePRs || CmdFlags.State == "open" {
+			log.Fatal("The -merged-only flag cannot be used with -exclude-prs or -state open")
+		}
+
+		// The merged pull requests are closed
+		CmdFlags.OnlyPRs, CmdFlags.State = true, "closed"
+	}
+
+	if len(CmdFlags.RepoFile) > 0 && (CmdFlags.AllRepos || len(CmdFlags.Query) > 0) {
+		log.Fatal("The -repo-file flag cannot be used with -all-repos or -query")
+	}
+
+	if len(CmdFlags.SinceMilestone) > 0 {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "s" {
+				log.Fatal("The -s and -since-milestone flags cannot be used together")
+			}
+		})
+
+		if len(CmdFlags.Query) > 0 {
+			log.Fatal("The -since-milestone flag cannot be used with -query")
+		}
+
+		if len(CmdFlags.StateFile) > 0 {
+			log.Fatal("The -since-milestone and -state-file flags cannot be used together")
+		}
+	}
+
+	if (CmdFlags.Project > 0) != (len(CmdFlags.ProjectColumn) > 0) {
+		log.Fatal("The -project and -project-column flags must be used together")
+	}
+
+	if CmdFlags.Project > 0 {
+		if len(CmdFlags.Query) > 0 || len(CmdFlags.RepoFile) > 0 || len(CmdFlags.ResumeFile) > 0 || len(CmdFlags.SinceMilestone) > 0 || CmdFlags.GraphQL {
+			log.Fatal("The -project flag cannot be used with -query, -repo-file, -resume-file, -since-milestone or -graphql")
+		}
+
+		if !CmdFlags.AllRepos && len(splitList(CmdFlags.Repository)) != 1 {
+			log.Fatal("The -project flag requires a single repository, or -all-repos for the organization projects")
+		}
+
+		if len(CmdFlags.Labels) > 0 || len(CmdFlags.Assignee) > 0 || len(CmdFlags.Author) > 0 || len(CmdFlags.Milestone) > 0 || CmdFlags.State != "all" {
+			log.Fatal("The -project flag cannot be used with -labels, -assignee, -author, -milestone or -state")
+		}
+	}
+
+	if CmdFlags.ExcludePRs && CmdFlags.ExcludeDrafts {
+		log.Fatal("The -exclude-prs and -exclude-drafts flags cannot be used together")
+	}
+
+	if CmdFlags.ExcludePRs && CmdFlags.OnlyPRs {
+		log.Fatal("The -exclude-prs and -only-prs flags cannot be used together")
+	}
+
+	if CmdFlags.JSONArray && (CmdFlags.JSON || CmdFlags.Markdown || CmdFlags.XLSX || CmdFlags.Summary || len(CmdFlags.SQLite) > 0 || templateOutput() || CmdFlags.SplitByAuthor || len(CmdFlags.ResumeFile) > 0) {
+		log.Fatal("The -json-array output cannot be combined with -json, -markdown, -xlsx, -summary, -sqlite, -template, -split-by-author or -resume-file")
+	}
+
+	if CmdFlags.XLSX && (CmdFlags.JSON || len(CmdFlags.Output) == 0) {
+		log.Fatal("The -xlsx output requires -out and cannot be combined with -json")
+	}
+
+	comma := ','
+
+	if CmdFlags.TabSeparated {
+		comma = '\t'
+	}
+
+	if len(CmdFlags.Delimiter) > 0 {
+		d, err := parseDelimiter(CmdFlags.Delimiter)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if CmdFlags.TabSeparated && d != '\t' {
+			log.Fatal("The -t and -delimiter flags cannot be used together")
+		}
+
+		comma = d
+	}
+
+	if CmdFlags.Markdown && (comma != ',' || CmdFlags.JSON || CmdFlags.XLSX || CmdFlags.Summary) {
+		log.Fatal("The -markdown output cannot be combined with -t, -delimiter, -json, -xlsx or -summary")
+	}
+
+	if CmdFlags.Pretty && (comma != ',' || CmdFlags.JSON || CmdFlags.JSONArray || CmdFlags.Markdown || CmdFlags.XLSX || CmdFlags.Summary || len(CmdFlags.SQLite) > 0 || templateOutput() || CmdFlags.SplitByAuthor || len(CmdFlags.Distribution) > 0 || len(CmdFlags.Output) > 0 || gzipOutput() || len(CmdFlags.ResumeFile) > 0) {
+		log.Fatal("The -pretty output cannot be combined with -t, -delimiter, -json, -json-array, -markdown, -xlsx, -summary, -sqlite, -template, -split-by-author, -distribution, -out, -gzip or -resume-file")
+	}
+
+	if CmdFlags.GraphQL && (len(CmdFlags.Query) > 0 || len(CmdFlags.ResumeFile) > 0 || len(CmdFlags.Author) > 0 || len(CmdFlags.Assignee) > 0 || len(CmdFlags.Milestone) > 0 || CmdFlags.Sort != "created") {
+		log.Fatal("The -graphql flag cannot be used with -query, -resume-file, -author, -assignee, -milestone or -sort other than created")
+	}
+
+	if CmdFlags.BOM && (CmdFlags.JSON || CmdFlags.JSONArray || CmdFlags.Markdown || CmdFlags.XLSX || CmdFlags.Summary || len(CmdFlags.SQLite) > 0 || templateOutput() || CmdFlags.Pretty || len(CmdFlags.Distribution) > 0 || CmdFlags.DryRun) {
+		log.Fatal("The -bom flag requires the CSV output and cannot be used with -json, -json-array, -markdown, -xlsx, -summary, -sqlite, -template, -pretty, -distribution or -dry-run")
+	}
+
+	if CmdFlags.TitleWidth < 0 {
+		log.Fatal("The -title-width value cannot be negative")
+	}
+
+	if len(CmdFlags.SQLite) > 0 && (CmdFlags.Markdown || CmdFlags.JSON || CmdFlags.XLSX || CmdFlags.Summary || len(CmdFlags.Output) > 0) {
+		log.Fatal("The -sqlite output cannot be combined with -markdown, -json, -xlsx, -summary or -out")
+	}
+
+	if templateOutput() {
+		if len(CmdFlags.Template) > 0 && len(CmdFlags.TemplateFile) > 0 {
+			log.Fatal("The -template and -template-file flags cannot be used together")
+		}
+
+		if CmdFlags.JSON || CmdFlags.Markdown || CmdFlags.XLSX || CmdFlags.Summary || len(CmdFlags.SQLite) > 0 || len(CmdFlags.Fields) > 0 {
+			log.Fatal("The -template output cannot be combined with -json, -markdown, -xlsx, -summary, -sqlite or -fields")
+		}
+	}
+
+	if len(CmdFlags.GroupBy) > 0 {
+		if !CmdFlags.Summary {
+			log.Fatal("The -group-by flag requires -summary")
+		}
+
+		err = validateFlagValue("group-by", CmdFlags.GroupBy, groupByValues())
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if CmdFlags.DryRun && (len(CmdFlags.Output) > 0 || len(CmdFlags.SQLite) > 0 || len(CmdFlags.ResumeFile) > 0) {
+		log.Fatal("The -dry-run flag cannot be used with -out, -sqlite or -resume-file")
+	}
+
+	if gzipOutput() && (CmdFlags.XLSX || len(CmdFlags.SQLite) > 0 || CmdFlags.DryRun) {
+		log.Fatal("The gzip output cannot be used with -xlsx, -sqlite or -dry-run")
+	}
+
+	if CmdFlags.SplitByAuthor {
+		if len(CmdFlags.OutputDir) == 0 {
+			log.Fatal("The -split-by-author flag requires -out-dir")
+		}
+
+		if len(CmdFlags.Output) > 0 || CmdFlags.JSON || CmdFlags.Markdown || CmdFlags.XLSX || CmdFlags.Summary || len(CmdFlags.SQLite) > 0 || templateOutput() || gzipOutput() || CmdFlags.DryRun || len(CmdFlags.ResumeFile) > 0 {
+			log.Fatal("The -split-by-author flag cannot be used with -out, -json, -markdown, -xlsx, -summary, -sqlite, -template, -gzip, -dry-run or -resume-file")
+		}
+	}
+
+	if len(CmdFlags.ResumeFile) > 0 && (CmdFlags.XLSX || CmdFlags.Summary) {
+		log.Fatal("The -resume-file flag cannot be used with -xlsx or -summary")
+	}
+
+	if len(CmdFlags.CommentsOutput) > 0 && (CmdFlags.DryRun || len(CmdFlags.ResumeFile) > 0) {
+		log.Fatal("The -comments-out flag cannot be used with -dry-run or -resume-file")
+	}
+
+	if len(CmdFlags.Fields) > 0 {
+		columns, err := fieldColumns(CmdFlags.Fields)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		selectColumns(columns)
+	}
+
+	var distribution Column
+
+	if len(CmdFlags.Distribution) > 0 {
+		columns, err := fieldColumns(CmdFlags.Distribution)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(columns) > 1 {
+			log.Fatal("The -distribution flag requires a single field")
+		}
+
+		if CmdFlags.Summary || CmdFlags.JSON || CmdFlags.JSONArray || CmdFlags.Markdown || CmdFlags.XLSX || len(CmdFlags.SQLite) > 0 || templateOutput() || CmdFlags.SplitByAuthor || len(CmdFlags.ResumeFile) > 0 {
+			log.Fatal("The -distribution flag cannot be used with -summary, -json, -json-array, -markdown, -xlsx, -sqlite, -template, -split-by-author or -resume-file")
+		}
+
+		distribution = columns[0]
+		selectColumns(columns)
+	}
+
+	if CmdFlags.Limit < 0 {
+		log.Fatal("The limit cannot be negative")
+	}
+
+	if CmdFlags.FromNumber < 0 || CmdFlags.ToNumber < 0 {
+		log.Fatal("The -from-number and -to-number values cannot be negative")
+	}
+
+	if CmdFlags.ToNumber > 0 && CmdFlags.ToNumber < CmdFlags.FromNumber {
+		log.Fatal("The -to-number value cannot be lower than the -from-number one")
+	}
+
+	if CmdFlags.FlushEvery < 0 {
+		log.Fatal("The -flush-every value cannot be negative")
+	}
+
+	if CmdFlags.PerPage < 1 || CmdFlags.PerPage > ghdump.MaxItemsPerPage {
+		clamped := ghdump.MaxItemsPerPage
+
+		if CmdFlags.PerPage < 1 {
+			clamped = 1
+		}
+
+		log.Printf("The -per-page value must be between 1 and %d, using %d", ghdump.MaxItemsPerPage, clamped)
+		CmdFlags.PerPage = clamped
+	}
+
+	if CmdFlags.Concurrency < 1 {
+		log.Fatal("The concurrency must be at least 1")
+	}
+
+	if len(CmdFlags.DatePreset) > 0 {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "date-format" {
+				log.Fatal("The -date-format and -date-preset flags cannot be used together")
+			}
+		})
+
+		err = validateFlagValue("date-preset", CmdFlags.DatePreset, DatePresetValues)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		CmdFlags.DateFormat = DatePresets[CmdFlags.DatePreset]
+	}
+
+	if CmdFlags.DateFormat == "rfc3339" {
+		CmdFlags.DateFormat = time.RFC3339
+	}
+
+	err = validateDateFormat(CmdFlags.DateFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	now := time.Now()
+
+	sinceDateTime, err := parseDate(CmdFlags.Since, now)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	untilDateTime := now
+
+	if len(CmdFlags.Until) > 0 {
+		untilDateTime, err = parseDate(CmdFlags.Until, now)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// The until date is inclusive, items are retrieved up to the end of that day
+		untilDateTime = untilDateTime.AddDate(0, 0, 1)
+	}
+
+	// The milestone creation date is known only when retrieving the items
+	if untilDateTime.Before(sinceDateTime) && len(CmdFlags.SinceMilestone) == 0 {
+		log.Fatal("The until date cannot be before the since date")
+	}
+
+	var tmpl *template.Template
+
+	// The template is parsed before starting to fail fast on syntax errors
+	if templateOutput() {
+		tmpl, err = loadTemplate(CmdFlags.Template, CmdFlags.TemplateFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	transport, err := gitHubTransport()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	httpClient, err := gitHubHTTPClient(transport)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if httpClient == nil {
+		if CmdFlags.NoLogin == false {
+			log.Fatal("No authentication could trigger API rate limiting: use authentication or use the flag -n to force.")
+		}
+
+		httpClient = &http.Client{Transport: transport}
+	}
+
+	if CmdFlags.Verbose {
+		httpClient = withDebugTransport(httpClient)
+	}
+
+	ghClient, err := newGitHubClient(httpClient)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if CmdFlags.Progress {
+		progress = newProgressReporter(os.Stderr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// An interrupt stops the dump, a second one terminates the process
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	go func(cancel context.CancelFunc) {
+		<-interrupt
+		signal.Stop(interrupt)
+		cancel()
+	}(cancel)
+
+	if CmdFlags.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, CmdFlags.Timeout)
+		defer cancel()
+	}
+
+	if CmdFlags.ShowRateLimit {
+		showRateLimits(ctx, ghClient, "before")
+	}
+
+	repos := []string{}
+
+	for _, repo := range splitList(CmdFlags.Repository) {
+		repos = append(repos, CmdFlags.Organization+"/"+repo)
+	}
+
+	if len(CmdFlags.RepoFile) > 0 {
+		repos, err = readRepositoryFile(CmdFlags.RepoFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// The search results are not restricted to the -o/-r repositories
+	if len(CmdFlags.Query) > 0 {
+		repos = nil
+	}
+
+	skipped := 0
+
+	// skipRepository reports whether the dump can continue with the next
+	// repository after err
+	skipRepository := func(repository string, err error) bool {
+		if !CmdFlags.ContinueOnError || ctx.Err() != nil {
+			return false
+		}
+
+		log.Printf("Skipping repository %s: %v", repository, err)
+		skipped++
+
+		return true
+	}
+
+	skipInvalid := skipRepository
+
+	// The project repository cannot be skipped, there is nothing else to dump
+	if CmdFlags.Project > 0 {
+		skipInvalid = func(string, error) bool { return false }
+	}
+
+	repos, err = validateRepositories(ctx, ghClient, repos, skipInvalid)
+	if err != nil {
+		fatalError(ctx, err)
+	}
+
+	// The project items are retrieved from the project cards instead
+	projectRepository := ""
+
+	if CmdFlags.Project > 0 && !CmdFlags.AllRepos {
+		projectRepository = repos[0]
+	}
+
+	if CmdFlags.Project > 0 {
+		repos = nil
+	}
+
+	if CmdFlags.AllRepos && CmdFlags.Project == 0 {
+		repos = []string{}
+
+		err = ghdump.IterateRepositories(ctx, ghClient, dumpOptions(""), func(r *github.Repository) error {
+			if (r.GetFork() && !CmdFlags.IncludeForks) || (r.GetArchived() && !CmdFlags.IncludeArchived) {
+				return nil
+			}
+
+			repos = append(repos, CmdFlags.Organization+"/"+r.GetName())
+			repositoryURLs[CmdFlags.Organization+"/"+r.GetName()] = r.GetHTMLURL()
+			return nil
+		})
+
+		if err != nil {
+			fatalError(ctx, err)
+		}
+	}
+
+	resuming := false
+
+	// The last runs are ignored when -s is set, but they are still recorded
+	var lastRuns *runState
+	sinceSet := false
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "s" {
+			sinceSet = true
+		}
+	})
+
+	if len(CmdFlags.StateFile) > 0 {
+		lastRuns, err = loadRunState(CmdFlags.StateFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// lastRunSince returns the since date of key, from the last run when recorded
+	lastRunSince := func(key string) time.Time {
+		if t, ok := lastRuns.LastRun(key); ok && !sinceSet {
+			return t
+		}
+
+		return sinceDateTime
+	}
+
+	if len(CmdFlags.ResumeFile) > 0 {
+		resume, resuming, err = loadResumeState(CmdFlags.ResumeFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+
+	if len(CmdFlags.Output) > 0 {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+
+		// When resuming the items are appended to the previous output
+		if resuming {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+
+		f, err := os.OpenFile(CmdFlags.Output, flags, 0666)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		out = f
+	}
+
+	var gz *gzip.Writer
+
+	if gzipOutput() {
+		gz = gzip.NewWriter(out)
+		out = gz
+	}
+
+	var w ItemWriter
+
+	switch {
+	case CmdFlags.DryRun:
+		w = newDryRunItemWriter(out)
+	case len(CmdFlags.Distribution) > 0:
+		w = newDistributionItemWriter(out, distribution)
+	case CmdFlags.Summary && len(CmdFlags.GroupBy) > 0:
+		w = newGroupItemWriter(out, CmdFlags.GroupBy, comma, CmdFlags.Header)
+	case CmdFlags.Summary:
+		w = newSummaryItemWriter(out)
+	case tmpl != nil:
+		w = newTemplateItemWriter(out, tmpl)
+	case CmdFlags.SplitByAuthor:
+		w, err = newSplitItemWriter(CmdFlags.OutputDir, outputColumns(), comma, !CmdFlags.NoHyperlink, CmdFlags.Header, CmdFlags.BOM)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case CmdFlags.JSON:
+		w = newJSONItemWriter(out)
+	case CmdFlags.JSONArray:
+		w = newJSONArrayItemWriter(out)
+	case CmdFlags.Pretty:
+		w = newPrettyItemWriter(out, outputColumns(), CmdFlags.TitleWidth)
+	case CmdFlags.Markdown:
+		w = newMarkdownItemWriter(out, outputColumns(), !CmdFlags.NoHyperlink)
+	case len(CmdFlags.SQLite) > 0:
+		w, err = newSQLiteItemWriter(CmdFlags.SQLite)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case CmdFlags.XLSX:
+		w, err = newXLSXItemWriter(out, outputColumns(), !CmdFlags.NoHyperlink)
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		w = newCSVItemWriter(out, outputColumns(), comma, !CmdFlags.NoHyperlink)
+	}
+
+	if CmdFlags.OutputOrder != "fetch" {
+		logger.Infof("The items are buffered in memory and written in %s order at the end of the dump", CmdFlags.OutputOrder)
+		w = newOrderedItemWriter(w, CmdFlags.OutputOrder)
+	}
+
+	if gz != nil {
+		w = &gzipItemWriter{w, gz}
+	}
+
+	if resume != nil {
+		resume.writer = w
+	}
+
+	var comments *commentsWriter
+
+	if len(CmdFlags.CommentsOutput) > 0 {
+		f, err := os.Create(CmdFlags.CommentsOutput)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		comments = newCommentsWriter(f, comma)
+
+		if CmdFlags.Header {
+			err := comments.WriteHeader()
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	// The resumed output already starts with the BOM
+	if CmdFlags.BOM && !CmdFlags.SplitByAuthor && !resuming {
+		_, err := io.WriteString(out, UTF8BOM)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if CmdFlags.Header && !resuming {
+		err := w.WriteHeader()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// The written items are flushed before exiting on errors and interrupts
+	abort := func(err error) {
+		closeErr := w.Close()
+		if closeErr != nil {
+			logger.Infof("Cannot flush the output: %v", closeErr)
+		}
+
+		if comments != nil {
+			closeErr = comments.Close()
+			if closeErr != nil {
+				logger.Infof("Cannot flush the comments output: %v", closeErr)
+			}
+		}
+
+		fatalError(ctx, err)
+	}
+
+	written, failures := 0, 0
+
+	writeItem := func(repository string, since time.Time, i *ghdump.Issue) error {
+		item := newItem(repository, i)
+
+		// The pull requests data is needed in dry runs only for filtering
+		if !CmdFlags.DryRun || CmdFlags.ExcludeDrafts {
+			err := addPullRequestData(ctx, ghClient, item)
+			if err != nil {
+				return err
+			}
+		}
+
+		if skipItem(item, since, untilDateTime) {
+			return nil
+		}
+
+		if CmdFlags.Participants && !CmdFlags.DryRun {
+			err := addParticipants(ctx, ghClient, item)
+			if err != nil {
+				return err
+			}
+		}
+
+		// A failed item does not stop the dump, the failures are reported
+		// with the exit code
+		err := w.Write(item)
+		if _, ok := err.(template.ExecError); ok {
+			// The template errors would fail every item
+			return err
+		}
+		if err != nil {
+			log.Printf("Cannot write item %s#%d: %v", item.Repository, item.Number, err)
+			failures++
+			return nil
+		}
+
+		if comments != nil {
+			err := comments.Write(ctx, ghClient, item)
+			if err != nil {
+				return err
+			}
+		}
+
+		progress.Item()
+		written++
+
+		// Streaming consumers receive the items while the dump is running
+		if CmdFlags.FlushEvery > 0 && written%CmdFlags.FlushEvery == 0 {
+			err := w.Flush()
+			if err != nil {
+				return err
+			}
+
+			if comments != nil {
+				err = comments.Flush()
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if CmdFlags.Limit > 0 && written >= CmdFlags.Limit {
+			return ghdump.ErrStop
+		}
+
+		return nil
+	}
+
+	if len(CmdFlags.Query) > 0 {
+		opts := dumpOptions(CmdFlags.Query)
+		since := lastRunSince(CmdFlags.Query)
+		setWindow(opts, since, untilDateTime)
+
+		err = ghdump.SearchIssues(ctx, ghClient, CmdFlags.Query, opts, func(i *ghdump.Issue) error {
+			return writeItem(ghdump.IssueRepository(&i.Issue), since, i)
+		})
+
+		if err != nil {
+			abort(err)
+		}
+
+		lastRuns.Done(CmdFlags.Query, now)
+	}
+
+	if CmdFlags.Project > 0 {
+		key := fmt.Sprintf("%s/projects/%d/%s", CmdFlags.Organization, CmdFlags.Project, CmdFlags.ProjectColumn)
+
+		opts := dumpOptions(key)
+
+		if len(projectRepository) > 0 {
+			opts.Owner, opts.Repository = splitRepository(projectRepository)
+		}
+
+		project, err := ghdump.FindProject(ctx, ghClient, opts, CmdFlags.Project)
+		if err != nil {
+			abort(err)
+		}
+
+		since := time.Time{}
+
+		// The cards are selected by column, the window applies only when set
+		// explicitly (or recorded by -state-file)
+		if _, ok := lastRuns.LastRun(key); ok || sinceSet || len(CmdFlags.Until) > 0 {
+			since = lastRunSince(key)
+			setWindow(opts, since, untilDateTime)
+		}
+
+		err = ghdump.IterateProjectColumn(ctx, ghClient, opts, project.GetID(), CmdFlags.ProjectColumn, func(i *ghdump.Issue) error {
+			return writeItem(ghdump.IssueRepository(&i.Issue), since, i)
+		})
+
+		if err != nil {
+			abort(err)
+		}
+
+		lastRuns.Done(key, now)
+	}
+
+	for _, repository := range repos {
+		if CmdFlags.Limit > 0 && written >= CmdFlags.Limit {
+			break
+		}
+
+		if resume.RepositoryCompleted(repository) {
+			continue
+		}
+
+		opts := dumpOptions(repository)
+		opts.Owner, opts.Repository = splitRepository(repository)
+		since := lastRunSince(repository)
+
+		if len(CmdFlags.SinceMilestone) > 0 {
+			m, err := ghdump.FindMilestone(ctx, ghClient, opts, CmdFlags.SinceMilestone)
+			if err != nil && skipRepository(repository, err) {
+				continue
+			}
+			if err != nil {
+				abort(err)
+			}
+
+			since = m.GetCreatedAt()
+		}
+
+		setWindow(opts, since, untilDateTime)
+
+		iterate := ghdump.IterateIssues
+
+		if CmdFlags.GraphQL {
+			iterate = ghdump.IterateIssuesGraphQL
+		}
+
+		err = iterate(ctx, ghClient, opts, func(i *ghdump.Issue) error {
+			return writeItem(repository, since, i)
+		})
+
+		if err != nil && skipRepository(repository, err) {
+			continue
+		}
+		if err != nil {
+			abort(err)
+		}
+
+		err = resume.RepositoryDone(repository)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		lastRuns.Done(repository, now)
+	}
+
+	err = w.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if comments != nil {
+		err = comments.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if CmdFlags.ShowRateLimit {
+		showRateLimits(ctx, ghClient, "after")
+	}
+
+	if CmdFlags.Stats {
+		elapsed := time.Since(start)
+		log.Printf("Dumped %d items in %s (%.1f items/s)", written, elapsed.Round(time.Millisecond), float64(written)/elapsed.Seconds())
+	}
+
+	if failures > 0 {
+		log.Fatalf("%d items could not be written", failures)
+	}
+
+	// The dumps stopped by -limit are incomplete, the next run repeats them
+	if !CmdFlags.DryRun && (CmdFlags.Limit == 0 || written < CmdFlags.Limit) {
+		err = lastRuns.Save()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// The skipped repositories are not completed, a resumed dump retries them
+	if skipped > 0 {
+		log.Fatalf("%d repositories were skipped", skipped)
+	}
+
+	err = resume.Done()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	progress.Done()
 }
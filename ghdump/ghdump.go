@@ -0,0 +1,870 @@
+// Package ghdump iterates over the issues and pull requests of GitHub
+// repositories, retrying on rate limits and transient errors.
+package ghdump
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	qs "github.com/google/go-querystring/query"
+)
+
+var MaxItemsPerPage = 100
+var SearchMaxResults = 1000
+var RetryBaseDelay = 1 * time.Second
+var RetryMaxDelay = 1 * time.Minute
+var AbuseRetryBuffer = 1 * time.Second
+
+// ErrStop is returned by the iteration functions to stop the iteration
+// without an error.
+var ErrStop = errors.New("Stop iteration")
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+}
+
+// Options selects the items to iterate over, the zero value of each field
+// leaves the corresponding filter unset.
+type Options struct {
+	Owner      string
+	Repository string
+
+	// OwnerType is "org" (the default) or "user", see ResolveOwnerType
+	OwnerType string
+
+	// Only the items created in the [Since, Until) window are returned
+	Since time.Time
+	Until time.Time
+
+	// UpdatedSince limits the retrieved items to the ones updated after it
+	// (the API since filter), regardless of their creation
+	UpdatedSince time.Time
+
+	State     string
+	Labels    []string
+	Assignee  string
+	Creator   string
+	Milestone string // number or title, "none" or "*"
+	Sort      string
+	Direction string
+
+	// Filters applied to the retrieved items, the API does not support them
+	ExcludePRs    bool
+	OnlyPRs       bool
+	MinComments   int
+	TitleContains string // case-insensitive
+
+	// ExcludeBots skips the items opened by bots, ExcludeAuthors the items
+	// opened by the specified (case-insensitive) logins
+	ExcludeBots    bool
+	ExcludeAuthors []string
+
+	// Only the items numbered in the [FromNumber, ToNumber] range are
+	// returned, the numbers are assigned in creation order
+	FromNumber int
+	ToNumber   int
+
+	// Number of pages retrieved in parallel
+	Concurrency int
+	// Number of items of the issues and search pages, MaxItemsPerPage when 0
+	PerPage int
+	// Maximum number of retries on API rate limits and transient errors
+	MaxRetries int
+
+	// StartPage is the first page retrieved, PageDone is called once all the
+	// items of a page were passed to the iteration function.
+	StartPage int
+	PageDone  func(page int) error
+
+	// Logf reports the rate limit waits and the retries
+	Logf func(format string, v ...interface{})
+}
+
+func (o *Options) logf(format string, v ...interface{}) {
+	if o.Logf != nil {
+		o.Logf(format, v...)
+	}
+}
+
+func (o *Options) waitRateLimit(ctx context.Context, err error) bool {
+	rateErr, ok := err.(*github.RateLimitError)
+	if !ok {
+		return false
+	}
+
+	wait := time.Until(rateErr.Rate.Reset.Time)
+	o.logf("API rate limit exceeded, waiting %s for reset", wait.Round(time.Second))
+
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+	}
+
+	return true
+}
+
+// waitAbuseRateLimit handles the secondary rate limits, which are triggered
+// by too many requests in a short time, waiting for the Retry-After delay (or
+// RetryMaxDelay when missing) plus AbuseRetryBuffer.
+func (o *Options) waitAbuseRateLimit(ctx context.Context, err error) bool {
+	abuseErr, ok := err.(*github.AbuseRateLimitError)
+	if !ok {
+		return false
+	}
+
+	wait := RetryMaxDelay
+
+	if abuseErr.RetryAfter != nil {
+		wait = *abuseErr.RetryAfter
+	}
+
+	wait += AbuseRetryBuffer
+	o.logf("API secondary rate limit exceeded, waiting %s", wait.Round(time.Second))
+
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+	}
+
+	return true
+}
+
+// waitRetryAfter handles the throttling responses that are neither a rate
+// limit nor a secondary rate limit error but carry a Retry-After header,
+// either in seconds or as an HTTP date.
+func (o *Options) waitRetryAfter(ctx context.Context, err error) bool {
+	e, ok := err.(*github.ErrorResponse)
+	if !ok || e.Response == nil {
+		return false
+	}
+
+	if e.Response.StatusCode != http.StatusForbidden && e.Response.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	retryAfter := e.Response.Header.Get("Retry-After")
+	if len(retryAfter) == 0 {
+		return false
+	}
+
+	var wait time.Duration
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(retryAfter); err == nil {
+		wait = time.Until(t)
+	} else {
+		return false
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+
+	o.logf("Request throttled (%s), waiting %s", e.Response.Status, wait.Round(time.Second))
+
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+	}
+
+	return true
+}
+
+func isTransientError(err error) bool {
+	switch e := err.(type) {
+	case *github.ErrorResponse:
+		switch e.Response.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	case *url.Error:
+		if _, ok := e.Err.(net.Error); ok || e.Err == io.EOF || e.Err == io.ErrUnexpectedEOF {
+			return true
+		}
+
+		return isTransientError(e.Err)
+	}
+
+	return false
+}
+
+func retryDelay(retries int) time.Duration {
+	delay := RetryBaseDelay << uint(retries)
+
+	if delay <= 0 || delay > RetryMaxDelay {
+		delay = RetryMaxDelay
+	}
+
+	// Jitter the delay between half and the whole computed value
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (o *Options) retryRequest(ctx context.Context, request func() (*github.Response, error)) (*github.Response, error) {
+	for retries := 0; ; retries++ {
+		response, err := request()
+
+		if err == nil || retries >= o.MaxRetries || ctx.Err() != nil {
+			return response, err
+		}
+
+		if o.waitRateLimit(ctx, err) || o.waitAbuseRateLimit(ctx, err) || o.waitRetryAfter(ctx, err) {
+			continue
+		}
+
+		if !isTransientError(err) {
+			return response, err
+		}
+
+		delay := retryDelay(retries)
+		o.logf("Request failed (%s), retrying in %s", err, delay.Round(time.Millisecond))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// IterateRepositories calls fn for each repository of the opts.Owner
+// organization, or user when opts.OwnerType is "user".
+func IterateRepositories(ctx context.Context, client *github.Client, opts *Options, fn func(*github.Repository) error) error {
+	listOptions := github.ListOptions{PerPage: MaxItemsPerPage}
+
+	for {
+		var repos []*github.Repository
+
+		response, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+			if opts.OwnerType == "user" {
+				repos, response, err = client.Repositories.List(ctx, opts.Owner, &github.RepositoryListOptions{ListOptions: listOptions})
+			} else {
+				repos, response, err = client.Repositories.ListByOrg(ctx, opts.Owner, &github.RepositoryListByOrgOptions{ListOptions: listOptions})
+			}
+			return
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, r := range repos {
+			err := fn(r)
+			if err == ErrStop {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if response.NextPage == 0 {
+			break
+		}
+
+		listOptions.Page = response.NextPage
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*github.ErrorResponse)
+	return ok && e.Response.StatusCode == http.StatusNotFound
+}
+
+// ResolveOwnerType returns whether opts.Owner is an "org" or a "user" when
+// opts.OwnerType is "auto", the organization is tried first. The owner
+// existence is checked in any case.
+func ResolveOwnerType(ctx context.Context, client *github.Client, opts *Options) (string, error) {
+	if opts.OwnerType != "user" {
+		_, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+			_, response, err = client.Organizations.Get(ctx, opts.Owner)
+			return
+		})
+		if err == nil || opts.OwnerType != "auto" || !isNotFound(err) {
+			return "org", err
+		}
+	}
+
+	_, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+		_, response, err = client.Users.Get(ctx, opts.Owner)
+		return
+	})
+
+	return "user", err
+}
+
+// FindMilestone returns the milestone of the opts.Owner/opts.Repository
+// repository with the specified title.
+func FindMilestone(ctx context.Context, client *github.Client, opts *Options, title string) (*github.Milestone, error) {
+	options := github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: MaxItemsPerPage},
+	}
+
+	for {
+		var milestones []*github.Milestone
+
+		response, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+			milestones, response, err = client.Issues.ListMilestones(ctx, opts.Owner, opts.Repository, &options)
+			return
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range milestones {
+			if m.GetTitle() == title {
+				return m, nil
+			}
+		}
+
+		if response.NextPage == 0 {
+			break
+		}
+
+		options.Page = response.NextPage
+	}
+
+	return nil, fmt.Errorf("Milestone %q not found in %s/%s", title, opts.Owner, opts.Repository)
+}
+
+func resolveMilestone(ctx context.Context, client *github.Client, opts *Options) (string, error) {
+	milestone := opts.Milestone
+
+	if _, err := strconv.Atoi(milestone); err == nil || milestone == "" || milestone == "none" || milestone == "*" {
+		return milestone, nil
+	}
+
+	m, err := FindMilestone(ctx, client, opts, milestone)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(m.GetNumber()), nil
+}
+
+// Issue adds the fields that are not available in the go-github version
+// used to the issue, the iteration functions pass the items to their
+// callbacks as Issue (e.g. with the MergedAt date of the pull requests).
+type Issue struct {
+	github.Issue
+
+	// MergedAt is set for the merged pull requests, the issues endpoints
+	// return it in the pull_request links.
+	MergedAt *time.Time `json:"-"`
+}
+
+func (i *Issue) UnmarshalJSON(data []byte) error {
+	var links struct {
+		PullRequest *struct {
+			MergedAt *time.Time `json:"merged_at"`
+		} `json:"pull_request"`
+	}
+
+	err := json.Unmarshal(data, &i.Issue)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, &links)
+	if err != nil {
+		return err
+	}
+
+	if links.PullRequest != nil {
+		i.MergedAt = links.PullRequest.MergedAt
+	}
+
+	return nil
+}
+
+// issuesMediaTypes is the Accept header of the go-github issues requests
+// (but not of the search requests), which enables the reactions.
+const issuesMediaTypes = "application/vnd.github.squirrel-girl-preview, application/vnd.github.symmetra-preview+json, application/vnd.github.sailor-v-preview+json"
+
+// getIssues decodes the issues endpoint u into v, the go-github methods
+// would decode the issues without the fields of Issue.
+func getIssues(ctx context.Context, client *github.Client, u, accept string, v interface{}) (*github.Response, error) {
+	req, err := client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept)
+	}
+
+	return client.Do(ctx, req, v)
+}
+
+// GetIssue returns the issue or pull request of the owner/repository
+// repository with the specified number.
+func GetIssue(ctx context.Context, client *github.Client, opts *Options, owner, repository string, number int) (*Issue, error) {
+	var i *Issue
+
+	_, err := opts.retryRequest(ctx, func() (*github.Response, error) {
+		i = &Issue{}
+		return getIssues(ctx, client, fmt.Sprintf("repos/%s/%s/issues/%d", owner, repository, number), issuesMediaTypes, i)
+	})
+
+	return i, err
+}
+
+type issuesPage struct {
+	index    int
+	issues   []*Issue
+	response *github.Response
+	err      error
+}
+
+// fetchIssuesPages retrieves the requested pages concurrently, the results
+// are returned in the same order as the pages.
+func fetchIssuesPages(ctx context.Context, client *github.Client, opts *Options, options github.IssueListByRepoOptions, pages []int) []issuesPage {
+	results := make(chan issuesPage)
+
+	for n, p := range pages {
+		go func(index, page int) {
+			o := options
+			o.Page = page
+
+			r := issuesPage{index: index}
+
+			params, err := qs.Values(o)
+			if err != nil {
+				r.err = err
+				results <- r
+				return
+			}
+
+			u := fmt.Sprintf("repos/%s/%s/issues?%s", opts.Owner, opts.Repository, params.Encode())
+
+			r.response, r.err = opts.retryRequest(ctx, func() (*github.Response, error) {
+				r.issues = nil
+				return getIssues(ctx, client, u, issuesMediaTypes, &r.issues)
+			})
+
+			results <- r
+		}(n, p)
+	}
+
+	ordered := make([]issuesPage, len(pages))
+
+	for range pages {
+		r := <-results
+		ordered[r.index] = r
+	}
+
+	return ordered
+}
+
+func (o *Options) perPage() int {
+	if o.PerPage > 0 && o.PerPage < MaxItemsPerPage {
+		return o.PerPage
+	}
+
+	return MaxItemsPerPage
+}
+
+func (o *Options) sortedByCreation() bool {
+	return o.Sort == "" || o.Sort == "created"
+}
+
+func (o *Options) descending() bool {
+	return o.Direction == "" || o.Direction == "desc"
+}
+
+// matchIssue applies the since/until window and the filters that are not
+// supported by the API. When sorting by creation date the iteration stops at
+// the first item outside of the window (depending on the direction), with any
+// other order all the items are compared.
+func (o *Options) matchIssue(i *github.Issue) (match, stop bool) {
+	if i.GetCreatedAt().Before(o.Since) {
+		return false, o.sortedByCreation() && o.descending()
+	}
+
+	if !o.Until.IsZero() && !i.GetCreatedAt().Before(o.Until) {
+		return false, o.sortedByCreation() && !o.descending()
+	}
+
+	if o.FromNumber > 0 && i.GetNumber() < o.FromNumber {
+		return false, o.sortedByCreation() && o.descending()
+	}
+
+	if o.ToNumber > 0 && i.GetNumber() > o.ToNumber {
+		return false, o.sortedByCreation() && !o.descending()
+	}
+
+	if (o.ExcludePRs && i.IsPullRequest()) || (o.OnlyPRs && !i.IsPullRequest()) {
+		return false, false
+	}
+
+	if i.GetComments() < o.MinComments {
+		return false, false
+	}
+
+	if len(o.TitleContains) > 0 && !strings.Contains(strings.ToLower(i.GetTitle()), strings.ToLower(o.TitleContains)) {
+		return false, false
+	}
+
+	if o.excludedAuthor(i.GetUser()) {
+		return false, false
+	}
+
+	return true, false
+}
+
+// IsBot reports whether the user is a bot account (e.g. dependabot[bot])
+func IsBot(u *github.User) bool {
+	return u.GetType() == "Bot" || strings.HasSuffix(u.GetLogin(), "[bot]")
+}
+
+func (o *Options) excludedAuthor(u *github.User) bool {
+	if o.ExcludeBots && IsBot(u) {
+		return true
+	}
+
+	for _, a := range o.ExcludeAuthors {
+		if strings.EqualFold(a, u.GetLogin()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IterateIssues calls fn for each issue and pull request of the
+// opts.Owner/opts.Repository repository matching opts.
+func IterateIssues(ctx context.Context, client *github.Client, opts *Options, fn func(*Issue) error) error {
+	milestone, err := resolveMilestone(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+
+	options := github.IssueListByRepoOptions{
+		Direction:   opts.Direction,
+		Sort:        opts.Sort,
+		State:       opts.State,
+		Labels:      opts.Labels,
+		Assignee:    opts.Assignee,
+		Creator:     opts.Creator,
+		Milestone:   milestone,
+		ListOptions: github.ListOptions{PerPage: opts.perPage()},
+	}
+
+	// The API since (an updated-at filter) limits the items retrieved when
+	// the iteration cannot stop early, as the items created after since were
+	// also updated after it.
+	if !opts.sortedByCreation() || !opts.descending() {
+		options.Since = opts.Since
+	}
+
+	if options.Since.Before(opts.UpdatedSince) {
+		options.Since = opts.UpdatedSince
+	}
+
+	nextPage, lastPage := opts.StartPage, 0
+
+	if nextPage < 1 {
+		nextPage = 1
+	}
+
+	for {
+		// The last page is known only after the first response, then up to
+		// opts.Concurrency pages are retrieved at once
+		pages := []int{nextPage}
+
+		for p := nextPage + 1; len(pages) < opts.Concurrency && p <= lastPage; p++ {
+			pages = append(pages, p)
+		}
+
+		for n, r := range fetchIssuesPages(ctx, client, opts, options, pages) {
+			if r.err != nil {
+				return r.err
+			}
+
+			for _, i := range r.issues {
+				match, stop := opts.matchIssue(&i.Issue)
+
+				if stop {
+					return nil
+				}
+
+				if !match {
+					continue
+				}
+
+				err := fn(i)
+				if err == ErrStop {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			if opts.PageDone != nil {
+				err := opts.PageDone(pages[n])
+				if err != nil {
+					return err
+				}
+			}
+
+			if r.response.NextPage == 0 {
+				return nil
+			}
+
+			nextPage = r.response.NextPage
+
+			if r.response.LastPage > lastPage {
+				lastPage = r.response.LastPage
+			}
+		}
+	}
+}
+
+// IteratePullRequests calls fn for each pull request of the
+// opts.Owner/opts.Repository repository matching opts, the pull requests are
+// retrieved from the issues endpoint.
+func IteratePullRequests(ctx context.Context, client *github.Client, opts *Options, fn func(*Issue) error) error {
+	prOpts := *opts
+	prOpts.ExcludePRs, prOpts.OnlyPRs = false, true
+
+	return IterateIssues(ctx, client, &prOpts, fn)
+}
+
+// issuesSearchResult is the github.IssuesSearchResult of the Issue items
+type issuesSearchResult struct {
+	Total             int     `json:"total_count"`
+	IncompleteResults bool    `json:"incomplete_results"`
+	Issues            []Issue `json:"items"`
+}
+
+// SearchIssues calls fn for each issue and pull request matching the search
+// query and opts, the owner, repository and API filters of opts are ignored
+// as they are expressed in the query. The pages are retrieved sequentially
+// because of the lower rate limit of the search API, which returns at most
+// SearchMaxResults items.
+func SearchIssues(ctx context.Context, client *github.Client, query string, opts *Options, fn func(*Issue) error) error {
+	options := github.SearchOptions{
+		Sort:        opts.Sort,
+		Order:       opts.Direction,
+		ListOptions: github.ListOptions{PerPage: opts.perPage(), Page: opts.StartPage},
+	}
+
+	// The search API sorts by best match by default
+	if len(options.Sort) == 0 {
+		options.Sort = "created"
+	}
+
+	if options.Page < 1 {
+		options.Page = 1
+	}
+
+	for {
+		var result issuesSearchResult
+
+		params, err := qs.Values(options)
+		if err != nil {
+			return err
+		}
+
+		// The query is encoded as by go-github
+		u := fmt.Sprintf("search/issues?q=%s&%s", url.PathEscape(strings.Replace(query, " ", "+", -1)), params.Encode())
+
+		response, err := opts.retryRequest(ctx, func() (*github.Response, error) {
+			result = issuesSearchResult{}
+			return getIssues(ctx, client, u, "", &result)
+		})
+		if err != nil {
+			return err
+		}
+
+		if options.Page == 1 && result.Total > SearchMaxResults {
+			opts.logf("Search returned %d results, only the first %d are available", result.Total, SearchMaxResults)
+		}
+
+		if result.IncompleteResults {
+			opts.logf("Search timed out, the results may be incomplete")
+		}
+
+		for n := range result.Issues {
+			i := &result.Issues[n]
+
+			match, stop := opts.matchIssue(&i.Issue)
+
+			if stop {
+				return nil
+			}
+
+			if !match {
+				continue
+			}
+
+			err := fn(i)
+			if err == ErrStop {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if opts.PageDone != nil {
+			err := opts.PageDone(options.Page)
+			if err != nil {
+				return err
+			}
+		}
+
+		if response.NextPage == 0 {
+			return nil
+		}
+
+		options.Page = response.NextPage
+	}
+}
+
+// IssueRepository returns the owner/name of the repository of i, which is
+// needed for the search results.
+func IssueRepository(i *github.Issue) string {
+	u, err := url.Parse(i.GetRepositoryURL())
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return strings.Join(parts[len(parts)-2:], "/")
+}
+
+// PullRequest adds the fields that are not available in the go-github
+// version used to the pull request.
+type PullRequest struct {
+	github.PullRequest
+	Draft *bool `json:"draft,omitempty"`
+}
+
+// GetDraft returns false for the pull requests without the draft field
+func (p *PullRequest) GetDraft() bool {
+	return p.Draft != nil && *p.Draft
+}
+
+// GetPullRequest returns the pull request of the opts.Owner/opts.Repository
+// repository with the specified number, which carries the data that is not
+// returned by the issues endpoint (one request for each pull request).
+func GetPullRequest(ctx context.Context, client *github.Client, opts *Options, number int) (*PullRequest, error) {
+	var pr *PullRequest
+
+	_, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+		req, err := client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/pulls/%d", opts.Owner, opts.Repository, number), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		pr = &PullRequest{}
+		return client.Do(ctx, req, pr)
+	})
+
+	return pr, err
+}
+
+// GetRepository returns the opts.Owner/opts.Repository repository
+func GetRepository(ctx context.Context, client *github.Client, opts *Options) (*github.Repository, error) {
+	var repo *github.Repository
+
+	_, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+		repo, response, err = client.Repositories.Get(ctx, opts.Owner, opts.Repository)
+		return
+	})
+
+	return repo, err
+}
+
+// ListReviews returns the reviews of the opts.Owner/opts.Repository pull
+// request with the specified number, in chronological order.
+func ListReviews(ctx context.Context, client *github.Client, opts *Options, number int) ([]*github.PullRequestReview, error) {
+	options := github.ListOptions{PerPage: MaxItemsPerPage}
+	reviews := []*github.PullRequestReview{}
+
+	for {
+		var page []*github.PullRequestReview
+
+		response, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+			page, response, err = client.PullRequests.ListReviews(ctx, opts.Owner, opts.Repository, number, &options)
+			return
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, page...)
+
+		if response.NextPage == 0 {
+			return reviews, nil
+		}
+
+		options.Page = response.NextPage
+	}
+}
+
+// IterateComments calls fn for each comment of the issue or pull request
+// number in opts.Repository, oldest first.
+func IterateComments(ctx context.Context, client *github.Client, opts *Options, number int, fn func(*github.IssueComment) error) error {
+	options := github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: MaxItemsPerPage}}
+
+	for {
+		var page []*github.IssueComment
+
+		response, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+			page, response, err = client.Issues.ListComments(ctx, opts.Owner, opts.Repository, number, &options)
+			return
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range page {
+			err := fn(c)
+			if err == ErrStop {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if response.NextPage == 0 {
+			return nil
+		}
+
+		options.Page = response.NextPage
+	}
+}
+
+// GetRateLimits returns the current rate limits, the request itself does not
+// count against them.
+func GetRateLimits(ctx context.Context, client *github.Client, opts *Options) (*github.RateLimits, error) {
+	var limits *github.RateLimits
+
+	_, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+		limits, response, err = client.RateLimits(ctx)
+		return
+	})
+
+	return limits, err
+}
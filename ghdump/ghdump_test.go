@@ -0,0 +1,183 @@
+package ghdump
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func TestMatchIssue(t *testing.T) {
+	since := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	before, inside := since.Add(-time.Second), since.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		opts      Options
+		createdAt time.Time
+		number    int
+		match     bool
+		stop      bool
+	}{
+		{"desc before since", Options{Since: since}, before, 10, false, true},
+		{"desc at since", Options{Since: since}, since, 10, true, false},
+		{"desc inside", Options{Since: since, Until: until}, inside, 10, true, false},
+		{"desc at until", Options{Since: since, Until: until}, until, 10, false, false},
+		{"asc before since", Options{Since: since, Direction: "asc"}, before, 10, false, false},
+		{"asc at since", Options{Since: since, Direction: "asc"}, since, 10, true, false},
+		{"asc at until", Options{Since: since, Until: until, Direction: "asc"}, until, 10, false, true},
+		{"updated sort before since", Options{Since: since, Sort: "updated"}, before, 10, false, false},
+		{"updated sort at until", Options{Until: until, Sort: "updated", Direction: "asc"}, until, 10, false, false},
+		{"desc before from number", Options{FromNumber: 10}, inside, 9, false, true},
+		{"desc at from number", Options{FromNumber: 10}, inside, 10, true, false},
+		{"desc after to number", Options{ToNumber: 10}, inside, 11, false, false},
+		{"asc before from number", Options{FromNumber: 10, Direction: "asc"}, inside, 9, false, false},
+		{"asc at to number", Options{ToNumber: 10, Direction: "asc"}, inside, 10, true, false},
+		{"asc after to number", Options{ToNumber: 10, Direction: "asc"}, inside, 11, false, true},
+	}
+
+	for _, test := range tests {
+		i := &github.Issue{Number: github.Int(test.number), CreatedAt: &test.createdAt}
+
+		match, stop := test.opts.matchIssue(i)
+
+		if match != test.match || stop != test.stop {
+			t.Errorf("%s: match %v stop %v, expected match %v stop %v", test.name, match, stop, test.match, test.stop)
+		}
+	}
+}
+
+func TestExcludedAuthorUnknownUser(t *testing.T) {
+	opts := Options{ExcludeBots: true, ExcludeAuthors: []string{"octocat"}}
+
+	if IsBot(nil) {
+		t.Errorf("Unknown user is a bot")
+	}
+
+	if opts.excludedAuthor(nil) {
+		t.Errorf("Unknown user is excluded")
+	}
+}
+
+// benchmarkPages is the number of issues pages served by newIssuesServer
+var benchmarkPages = 10
+
+// newIssuesServer serves the issues pages of the o/r repository with the
+// pagination links, each response is delayed as the API latency. The items
+// with an even number are pull requests, as in the issues endpoint.
+func newIssuesServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+
+		link := func(rel string, p int) string {
+			q := r.URL.Query()
+			q.Set("page", strconv.Itoa(p))
+			return fmt.Sprintf("<http://%s%s?%s>; rel=%q", r.Host, r.URL.Path, q.Encode(), rel)
+		}
+
+		links := link("last", benchmarkPages)
+
+		if page < benchmarkPages {
+			links = link("next", page+1) + ", " + links
+		}
+
+		w.Header().Set("Link", links)
+
+		issues := []map[string]interface{}{}
+		created := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+
+		for n := 0; n < MaxItemsPerPage; n++ {
+			number := (benchmarkPages-page+1)*MaxItemsPerPage - n
+			i := map[string]interface{}{"number": number, "created_at": created.Add(time.Duration(number) * time.Hour)}
+
+			if number%2 == 0 {
+				i["pull_request"] = map[string]string{"url": fmt.Sprintf("http://%s/repos/o/r/pulls/%d", r.Host, number)}
+			}
+
+			issues = append(issues, i)
+		}
+
+		json.NewEncoder(w).Encode(issues)
+	}))
+}
+
+func newTestClient(server *httptest.Server) *github.Client {
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	return client
+}
+
+func TestIterateIssuesMixed(t *testing.T) {
+	server := newIssuesServer(0)
+	defer server.Close()
+
+	opts := &Options{Owner: "o", Repository: "r", Concurrency: 4}
+	seen := map[int]bool{}
+
+	err := IterateIssues(context.Background(), newTestClient(server), opts, func(i *Issue) error {
+		if seen[i.GetNumber()] {
+			t.Errorf("Item %d iterated twice", i.GetNumber())
+		}
+
+		if i.IsPullRequest() != (i.GetNumber()%2 == 0) {
+			t.Errorf("Item %d is pull request %v", i.GetNumber(), i.IsPullRequest())
+		}
+
+		seen[i.GetNumber()] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != benchmarkPages*MaxItemsPerPage {
+		t.Errorf("Iterated %d items, expected %d", len(seen), benchmarkPages*MaxItemsPerPage)
+	}
+}
+
+func benchmarkIterateIssues(b *testing.B, concurrency int) {
+	server := newIssuesServer(20 * time.Millisecond)
+	defer server.Close()
+
+	client := newTestClient(server)
+	opts := &Options{Owner: "o", Repository: "r", Concurrency: concurrency}
+
+	for n := 0; n < b.N; n++ {
+		count := 0
+
+		err := IterateIssues(context.Background(), client, opts, func(*Issue) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if count != benchmarkPages*MaxItemsPerPage {
+			b.Fatalf("Iterated %d issues, expected %d", count, benchmarkPages*MaxItemsPerPage)
+		}
+	}
+}
+
+func BenchmarkIterateIssues(b *testing.B) {
+	for _, concurrency := range []int{1, 4} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			benchmarkIterateIssues(b, concurrency)
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package ghdump
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// graphQLItemsQuery retrieves a page of issues and a page of pull requests,
+// each connection only when requested and from its own cursor.
+const graphQLItemsQuery = `query($owner: String!, $name: String!, $first: Int!, $labels: [String!], $direction: OrderDirection!,
+	$issues: Boolean!, $issuesCursor: String, $issueStates: [IssueState!],
+	$pullRequests: Boolean!, $pullRequestsCursor: String, $pullRequestStates: [PullRequestState!]) {
+  repository(owner: $owner, name: $name) {
+    issues(first: $first, after: $issuesCursor, labels: $labels, states: $issueStates, orderBy: {field: CREATED_AT, direction: $direction}) @include(if: $issues) {
+      pageInfo { hasNextPage endCursor }
+      nodes { ...issueFields }
+    }
+    pullRequests(first: $first, after: $pullRequestsCursor, labels: $labels, states: $pullRequestStates, orderBy: {field: CREATED_AT, direction: $direction}) @include(if: $pullRequests) {
+      pageInfo { hasNextPage endCursor }
+      nodes { ...pullRequestFields }
+    }
+  }
+}
+
+fragment issueFields on Issue {` + graphQLItemFields + `}
+
+fragment pullRequestFields on PullRequest {` + graphQLItemFields + `  mergedAt
+}`
+
+// graphQLItemFields are the fields of both the issues and the pull requests
+const graphQLItemFields = `
+  id number title body state url createdAt updatedAt closedAt
+  author { login url ... on Bot { __typename } }
+  labels(first: 100) { nodes { name color } }
+  assignees(first: 100) { nodes { login url } }
+  milestone { number title dueOn createdAt }
+  comments { totalCount }
+  reactionGroups { content reactors { totalCount } }
+`
+
+type graphQLUser struct {
+	Login    string `json:"login"`
+	URL      string `json:"url"`
+	TypeName string `json:"__typename"`
+}
+
+type graphQLItem struct {
+	ID        string       `json:"id"`
+	Number    int          `json:"number"`
+	Title     string       `json:"title"`
+	Body      string       `json:"body"`
+	State     string       `json:"state"`
+	URL       string       `json:"url"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+	ClosedAt  *time.Time   `json:"closedAt"`
+	MergedAt  *time.Time   `json:"mergedAt"`
+	Author    *graphQLUser `json:"author"`
+	Labels    struct {
+		Nodes []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Assignees struct {
+		Nodes []graphQLUser `json:"nodes"`
+	} `json:"assignees"`
+	Milestone *struct {
+		Number    int        `json:"number"`
+		Title     string     `json:"title"`
+		DueOn     *time.Time `json:"dueOn"`
+		CreatedAt time.Time  `json:"createdAt"`
+	} `json:"milestone"`
+	Comments struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"comments"`
+	ReactionGroups []struct {
+		Content  string `json:"content"`
+		Reactors struct {
+			TotalCount int `json:"totalCount"`
+		} `json:"reactors"`
+	} `json:"reactionGroups"`
+}
+
+type graphQLConnection struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []graphQLItem `json:"nodes"`
+}
+
+type graphQLResponse struct {
+	Data struct {
+		Repository *struct {
+			Issues       *graphQLConnection `json:"issues"`
+			PullRequests *graphQLConnection `json:"pullRequests"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func graphQLGitHubUser(u *graphQLUser) *github.User {
+	if u == nil {
+		return nil
+	}
+
+	user := &github.User{Login: github.String(u.Login), HTMLURL: github.String(u.URL)}
+
+	if u.TypeName == "Bot" {
+		user.Type = github.String("Bot")
+	}
+
+	return user
+}
+
+// issue maps the GraphQL item to the REST issue, the pull requests are
+// recognized by their PullRequestLinks as in the issues endpoint.
+func (g *graphQLItem) issue(repositoryURL string, pullRequest bool) *Issue {
+	i := &github.Issue{
+		NodeID:        github.String(g.ID),
+		Number:        github.Int(g.Number),
+		Title:         github.String(g.Title),
+		Body:          github.String(g.Body),
+		State:         github.String("open"),
+		HTMLURL:       github.String(g.URL),
+		RepositoryURL: github.String(repositoryURL),
+		CreatedAt:     &g.CreatedAt,
+		UpdatedAt:     &g.UpdatedAt,
+		ClosedAt:      g.ClosedAt,
+		User:          graphQLGitHubUser(g.Author),
+		Comments:      github.Int(g.Comments.TotalCount),
+	}
+
+	if g.State != "OPEN" {
+		i.State = github.String("closed")
+	}
+
+	for _, l := range g.Labels.Nodes {
+		i.Labels = append(i.Labels, github.Label{Name: github.String(l.Name), Color: github.String(l.Color)})
+	}
+
+	for n := range g.Assignees.Nodes {
+		i.Assignees = append(i.Assignees, graphQLGitHubUser(&g.Assignees.Nodes[n]))
+	}
+
+	i.Reactions = &github.Reactions{TotalCount: github.Int(0)}
+
+	// The reactions without a Reactions field (e.g. rocket) are only counted
+	for _, r := range g.ReactionGroups {
+		count := github.Int(r.Reactors.TotalCount)
+		*i.Reactions.TotalCount += r.Reactors.TotalCount
+
+		switch r.Content {
+		case "THUMBS_UP":
+			i.Reactions.PlusOne = count
+		case "THUMBS_DOWN":
+			i.Reactions.MinusOne = count
+		case "LAUGH":
+			i.Reactions.Laugh = count
+		case "CONFUSED":
+			i.Reactions.Confused = count
+		case "HEART":
+			i.Reactions.Heart = count
+		case "HOORAY":
+			i.Reactions.Hooray = count
+		}
+	}
+
+	if g.Milestone != nil {
+		i.Milestone = &github.Milestone{
+			Number:    github.Int(g.Milestone.Number),
+			Title:     github.String(g.Milestone.Title),
+			DueOn:     g.Milestone.DueOn,
+			CreatedAt: &g.Milestone.CreatedAt,
+		}
+	}
+
+	if pullRequest {
+		i.PullRequestLinks = &github.PullRequestLinks{HTMLURL: github.String(g.URL)}
+	}
+
+	return &Issue{Issue: *i, MergedAt: g.MergedAt}
+}
+
+// graphQLURL returns the GraphQL endpoint of the client, which is
+// /api/graphql on GitHub Enterprise (where the REST API is /api/v3).
+func graphQLURL(client *github.Client) *url.URL {
+	u := *client.BaseURL
+
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+	} else {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/graphql"
+	}
+
+	return &u
+}
+
+func graphQLStates(state string, closed ...string) []string {
+	switch state {
+	case "open":
+		return []string{"OPEN"}
+	case "closed":
+		return closed
+	}
+
+	return nil
+}
+
+// graphQLItems is the issues or the pull requests connection being iterated
+type graphQLItems struct {
+	enabled       bool
+	pullRequest   bool
+	repositoryURL string
+	cursor        *string
+	done          bool
+	items         []*Issue
+}
+
+func (g *graphQLItems) next() bool {
+	return g.enabled && !g.done && len(g.items) == 0
+}
+
+func (g *graphQLItems) add(c *graphQLConnection) {
+	if c == nil {
+		g.done = true
+		return
+	}
+
+	for n := range c.Nodes {
+		g.items = append(g.items, c.Nodes[n].issue(g.repositoryURL, g.pullRequest))
+	}
+
+	g.cursor, g.done = github.String(c.PageInfo.EndCursor), !c.PageInfo.HasNextPage
+}
+
+func fetchGraphQLItems(ctx context.Context, client *github.Client, opts *Options, issues, pullRequests *graphQLItems) error {
+	direction := "DESC"
+
+	if !opts.descending() {
+		direction = "ASC"
+	}
+
+	variables := map[string]interface{}{
+		"owner":              opts.Owner,
+		"name":               opts.Repository,
+		"first":              opts.perPage(),
+		"direction":          direction,
+		"issues":             issues.next(),
+		"issuesCursor":       issues.cursor,
+		"issueStates":        graphQLStates(opts.State, "CLOSED"),
+		"pullRequests":       pullRequests.next(),
+		"pullRequestsCursor": pullRequests.cursor,
+		"pullRequestStates":  graphQLStates(opts.State, "CLOSED", "MERGED"),
+	}
+
+	if len(opts.Labels) > 0 {
+		variables["labels"] = opts.Labels
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"query": graphQLItemsQuery, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	var result graphQLResponse
+
+	_, err = opts.retryRequest(ctx, func() (*github.Response, error) {
+		req, err := http.NewRequest("POST", graphQLURL(client).String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		result = graphQLResponse{}
+		return client.Do(ctx, req, &result)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("GraphQL query failed: %s", result.Errors[0].Message)
+	}
+
+	if result.Data.Repository == nil {
+		return fmt.Errorf("Repository %s/%s not found", opts.Owner, opts.Repository)
+	}
+
+	if issues.next() {
+		issues.add(result.Data.Repository.Issues)
+	}
+
+	if pullRequests.next() {
+		pullRequests.add(result.Data.Repository.PullRequests)
+	}
+
+	return nil
+}
+
+// hasLabels reports whether i has all the labels (case-insensitive), as the
+// REST labels filter.
+func hasLabels(i *github.Issue, labels []string) bool {
+	for _, l := range labels {
+		found := false
+
+		for _, il := range i.Labels {
+			if strings.EqualFold(il.GetName(), l) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IterateIssuesGraphQL is the experimental equivalent of IterateIssues based
+// on the GraphQL API, the issues and the pull requests are retrieved from
+// separate connections and merged in creation order. Only the creation sort
+// is supported, as well as the state and labels API filters (the items with
+// all the labels are retrieved as with the REST API).
+func IterateIssuesGraphQL(ctx context.Context, client *github.Client, opts *Options, fn func(*Issue) error) error {
+	if !opts.sortedByCreation() {
+		return errors.New("The GraphQL iteration supports only the creation sort")
+	}
+
+	if len(opts.Assignee) > 0 || len(opts.Creator) > 0 || len(opts.Milestone) > 0 {
+		return errors.New("The GraphQL iteration does not support the assignee, creator and milestone filters")
+	}
+
+	// The REST repository URL identifies the repository of the items
+	repositoryURL := fmt.Sprintf("%srepos/%s/%s", client.BaseURL, opts.Owner, opts.Repository)
+
+	issues := &graphQLItems{enabled: !opts.OnlyPRs, repositoryURL: repositoryURL}
+	pullRequests := &graphQLItems{enabled: !opts.ExcludePRs, pullRequest: true, repositoryURL: repositoryURL}
+
+	for {
+		if issues.next() || pullRequests.next() {
+			err := fetchGraphQLItems(ctx, client, opts, issues, pullRequests)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(issues.items) == 0 && len(pullRequests.items) == 0 {
+			return nil
+		}
+
+		// The next item is the first of the two in the iteration order
+		next := issues
+
+		if len(issues.items) == 0 {
+			next = pullRequests
+		} else if len(pullRequests.items) > 0 {
+			i, p := issues.items[0].GetCreatedAt(), pullRequests.items[0].GetCreatedAt()
+
+			if (opts.descending() && p.After(i)) || (!opts.descending() && p.Before(i)) {
+				next = pullRequests
+			}
+		}
+
+		i := next.items[0]
+		next.items = next.items[1:]
+
+		match, stop := opts.matchIssue(&i.Issue)
+
+		if stop {
+			return nil
+		}
+
+		// The GraphQL labels filter matches the items with any of the labels
+		if !match || !hasLabels(&i.Issue, opts.Labels) {
+			continue
+		}
+
+		err := fn(i)
+		if err == ErrStop {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package ghdump
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// FindProject returns the project (classic) with the specified number of the
+// opts.Owner/opts.Repository repository, or of the opts.Owner organization
+// when opts.Repository is empty.
+func FindProject(ctx context.Context, client *github.Client, opts *Options, number int) (*github.Project, error) {
+	options := github.ProjectListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: MaxItemsPerPage},
+	}
+
+	for {
+		var projects []*github.Project
+
+		response, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+			if len(opts.Repository) > 0 {
+				projects, response, err = client.Repositories.ListProjects(ctx, opts.Owner, opts.Repository, &options)
+			} else {
+				projects, response, err = client.Organizations.ListProjects(ctx, opts.Owner, &options)
+			}
+			return
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range projects {
+			if p.GetNumber() == number {
+				return p, nil
+			}
+		}
+
+		if response.NextPage == 0 {
+			return nil, fmt.Errorf("Project %d not found", number)
+		}
+
+		options.Page = response.NextPage
+	}
+}
+
+// parseIssueURL returns the owner, repository and number of the issue API URL
+// of a project card content, e.g. https://api.github.com/repos/o/r/issues/1
+func parseIssueURL(u string) (string, string, int, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+
+	if len(parts) < 4 || parts[len(parts)-2] != "issues" {
+		return "", "", 0, fmt.Errorf("Invalid project card content %s", u)
+	}
+
+	number, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return "", "", 0, fmt.Errorf("Invalid project card content %s", u)
+	}
+
+	return parts[len(parts)-4], parts[len(parts)-3], number, nil
+}
+
+func findProjectColumn(ctx context.Context, client *github.Client, opts *Options, projectID int64, name string) (*github.ProjectColumn, error) {
+	options := github.ListOptions{PerPage: MaxItemsPerPage}
+
+	for {
+		var columns []*github.ProjectColumn
+
+		response, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+			columns, response, err = client.Projects.ListProjectColumns(ctx, projectID, &options)
+			return
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range columns {
+			if strings.EqualFold(c.GetName(), name) {
+				return c, nil
+			}
+		}
+
+		if response.NextPage == 0 {
+			return nil, fmt.Errorf("Project column %q not found", name)
+		}
+
+		options.Page = response.NextPage
+	}
+}
+
+// IterateProjectColumn calls fn for each issue and pull request of the cards
+// in the project column with the specified (case-insensitive) name, in the
+// column order. The notes are skipped, and each card content is retrieved
+// with one request. The since/until window and the filters not supported by
+// the API are applied, but not the API filters of opts.
+func IterateProjectColumn(ctx context.Context, client *github.Client, opts *Options, projectID int64, name string, fn func(*Issue) error) error {
+	column, err := findProjectColumn(ctx, client, opts, projectID, name)
+	if err != nil {
+		return err
+	}
+
+	options := github.ProjectCardListOptions{ListOptions: github.ListOptions{PerPage: MaxItemsPerPage}}
+
+	for {
+		var cards []*github.ProjectCard
+
+		response, err := opts.retryRequest(ctx, func() (response *github.Response, err error) {
+			cards, response, err = client.Projects.ListProjectCards(ctx, column.GetID(), &options)
+			return
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range cards {
+			if len(c.GetContentURL()) == 0 {
+				continue
+			}
+
+			owner, repo, number, err := parseIssueURL(c.GetContentURL())
+			if err != nil {
+				return err
+			}
+
+			i, err := GetIssue(ctx, client, opts, owner, repo, number)
+			if err != nil {
+				return err
+			}
+
+			// The cards are not sorted by creation, the window does not stop
+			// the iteration
+			if match, _ := opts.matchIssue(&i.Issue); !match {
+				continue
+			}
+
+			err = fn(i)
+			if err == ErrStop {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if response.NextPage == 0 {
+			return nil
+		}
+
+		options.Page = response.NextPage
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+	"io.bytenix.com/ghdump/ghdump"
+)
+
+var testCreatedAt = time.Date(2018, 3, 1, 10, 30, 0, 0, time.UTC)
+
+func testIssue(number int) *ghdump.Issue {
+	return &ghdump.Issue{Issue: github.Issue{
+		Number:    github.Int(number),
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/golang/go/issues/%d", number)),
+		Title:     github.String(fmt.Sprintf("Issue %d", number)),
+		State:     github.String("open"),
+		CreatedAt: &testCreatedAt,
+		User:      &github.User{Login: github.String("octocat"), HTMLURL: github.String("https://github.com/octocat")},
+	}}
+}
+
+func itemColumn(t *testing.T, field string) Column {
+	for _, c := range ItemColumns {
+		if c.Field == field {
+			return c
+		}
+	}
+
+	t.Fatalf("Column %s not found", field)
+	return Column{}
+}
+
+func TestNewItemClosedAt(t *testing.T) {
+	closedAt := testCreatedAt.Add(50 * time.Hour)
+
+	open := testIssue(1)
+
+	closed := testIssue(2)
+	closed.State, closed.ClosedAt = github.String("closed"), &closedAt
+
+	column := itemColumn(t, "closed_at")
+
+	item := newItem("golang/go", open)
+
+	if item.ClosedAt != nil {
+		t.Errorf("Open item closed at %v", item.ClosedAt)
+	}
+
+	if v := column.Value(item); v != "" {
+		t.Errorf("Open item closed_at is %q, expected empty", v)
+	}
+
+	item = newItem("golang/go", closed)
+
+	if item.ClosedAt == nil || !item.ClosedAt.Equal(closedAt) {
+		t.Errorf("Closed item closed at %v, expected %v", item.ClosedAt, closedAt)
+	}
+
+	if v, expected := column.Value(item), closedAt.Format(GoogleSheetDateFormat); v != expected {
+		t.Errorf("Closed item closed_at is %q, expected %q", v, expected)
+	}
+}
+
+func TestCSVBody(t *testing.T) {
+	CmdFlags.Body = true
+	defer func() { CmdFlags.Body = false }()
+
+	body := "First line, with a \"quote\"\nSecond line"
+
+	i := testIssue(1)
+	i.Body = github.String(body)
+
+	columns := append(append([]Column{}, ItemColumns...), BodyColumn)
+
+	b := bytes.Buffer{}
+	w := newCSVItemWriter(&b, columns, ',', false)
+
+	err := w.WriteHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Write(newItem("golang/go", i))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Read %d rows, expected 2", len(rows))
+	}
+
+	if v := rows[1][len(columns)-1]; v != body {
+		t.Errorf("Read body %q, expected %q", v, body)
+	}
+}
+
+func TestNewItemUnknownUser(t *testing.T) {
+	i := testIssue(1)
+	i.User = nil
+
+	item := newItem("golang/go", i)
+
+	if item.User != UnknownUser || item.UserHTMLURL != "" {
+		t.Errorf("Item user %q (%q), expected %q without URL", item.User, item.UserHTMLURL, UnknownUser)
+	}
+}
@@ -2,6 +2,8 @@ module io.bytenix.com/ghdump
 
 require (
 	github.com/google/go-github v17.0.0+incompatible
-	github.com/google/go-querystring v1.0.0 // indirect
+	github.com/google/go-querystring v1.0.0
+	github.com/mattn/go-sqlite3 v1.14.22
 	golang.org/x/oauth2 v0.0.0-20190115181402-5dab4167f31c
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"compress/gzip"
+	"strings"
+)
+
+var GzipExtension = ".gz"
+
+func gzipOutput() bool {
+	return CmdFlags.Gzip || strings.HasSuffix(CmdFlags.Output, GzipExtension)
+}
+
+// gzipItemWriter compresses the output of an ItemWriter, the compressed data
+// is flushed with the items so that a resumed dump does not lose any of them.
+// The members appended when resuming are decompressed as a single stream.
+type gzipItemWriter struct {
+	ItemWriter
+	gz *gzip.Writer
+}
+
+func (g *gzipItemWriter) Flush() error {
+	err := g.ItemWriter.Flush()
+	if err != nil {
+		return err
+	}
+
+	return g.gz.Flush()
+}
+
+func (g *gzipItemWriter) Close() error {
+	err := g.ItemWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	return g.gz.Close()
+}
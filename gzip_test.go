@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+// writeItems writes the header (when set) and the items to w and closes it
+func writeItems(t *testing.T, w ItemWriter, header bool, items ...*Item) {
+	if header {
+		err := w.WriteHeader()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, item := range items {
+		err := w.Write(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func gunzip(t *testing.T, data []byte) string {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestGzipItemWriter(t *testing.T) {
+	first, second := newItem("golang/go", testIssue(1)), newItem("golang/go", testIssue(2))
+
+	plain := bytes.Buffer{}
+	writeItems(t, newCSVItemWriter(&plain, ItemColumns, ',', false), true, first, second)
+
+	compressed := bytes.Buffer{}
+	gz := gzip.NewWriter(&compressed)
+	writeItems(t, &gzipItemWriter{newCSVItemWriter(gz, ItemColumns, ',', false), gz}, true, first, second)
+
+	if v := gunzip(t, compressed.Bytes()); v != plain.String() {
+		t.Errorf("Decompressed %q, expected %q", v, plain.String())
+	}
+
+	// A resumed dump appends a gzip member without the header
+	appended := bytes.Buffer{}
+	gz = gzip.NewWriter(&appended)
+	writeItems(t, &gzipItemWriter{newCSVItemWriter(gz, ItemColumns, ',', false), gz}, true, first)
+	gz = gzip.NewWriter(&appended)
+	writeItems(t, &gzipItemWriter{newCSVItemWriter(gz, ItemColumns, ',', false), gz}, false, second)
+
+	if v := gunzip(t, appended.Bytes()); v != plain.String() {
+		t.Errorf("Decompressed appended members %q, expected %q", v, plain.String())
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	LogLevelQuiet = iota
+	LogLevelInfo
+	LogLevelDebug
+)
+
+// leveledLogger writes informational and debug messages to stderr so that
+// stdout carries only the dumped data, errors are still reported through
+// log.Fatal regardless of the level.
+type leveledLogger struct {
+	level int
+	l     *log.Logger
+}
+
+var logger = &leveledLogger{LogLevelInfo, log.New(os.Stderr, "", log.LstdFlags)}
+
+func (l *leveledLogger) Infof(format string, v ...interface{}) {
+	if l.level >= LogLevelInfo {
+		l.l.Printf(format, v...)
+	}
+}
+
+func (l *leveledLogger) Debugf(format string, v ...interface{}) {
+	if l.level >= LogLevelDebug {
+		l.l.Printf(format, v...)
+	}
+}
+
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		logger.Debugf("%s %s failed after %s: %v", req.Method, req.URL, time.Since(start).Round(time.Millisecond), err)
+		return nil, err
+	}
+
+	logger.Debugf("%s %s %s in %s", req.Method, req.URL, resp.Status, time.Since(start).Round(time.Millisecond))
+
+	return resp, nil
+}
+
+func withDebugTransport(c *http.Client) *http.Client {
+	if c == nil {
+		c = &http.Client{}
+	}
+
+	next := c.Transport
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	c.Transport = &debugTransport{next}
+
+	return c
+}
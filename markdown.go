@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+
+// markdownItemWriter writes the items as the rows of a GitHub-flavored
+// Markdown table, the header row is required for the table to be rendered.
+type markdownItemWriter struct {
+	w          *bufio.Writer
+	columns    []Column
+	hyperlinks bool
+}
+
+func newMarkdownItemWriter(w io.Writer, columns []Column, hyperlinks bool) *markdownItemWriter {
+	return &markdownItemWriter{bufio.NewWriter(w), columns, hyperlinks}
+}
+
+var markdownLinkTextReplacer = strings.NewReplacer("[", "\\[", "]", "\\]")
+
+func markdownHyperlink(value, link string) string {
+	return fmt.Sprintf("[%s](%s)", markdownLinkTextReplacer.Replace(value), link)
+}
+
+func (m *markdownItemWriter) writeRow(row []string) error {
+	_, err := fmt.Fprintf(m.w, "| %s |\n", strings.Join(row, " | "))
+	return err
+}
+
+func (m *markdownItemWriter) WriteHeader() error {
+	header := make([]string, len(m.columns))
+	separator := make([]string, len(m.columns))
+
+	for n, col := range m.columns {
+		header[n] = col.Name
+		separator[n] = "---"
+	}
+
+	err := m.writeRow(header)
+	if err != nil {
+		return err
+	}
+
+	return m.writeRow(separator)
+}
+
+func (m *markdownItemWriter) Write(item *Item) error {
+	var hyperlink func(string, string) string
+
+	if m.hyperlinks {
+		hyperlink = markdownHyperlink
+	}
+
+	row := itemRow(m.columns, item, hyperlink)
+
+	// The links are GitHub URLs, escaping them is harmless
+	for n := range row {
+		row[n] = markdownEscaper.Replace(row[n])
+	}
+
+	return m.writeRow(row)
+}
+
+func (m *markdownItemWriter) Flush() error {
+	return m.w.Flush()
+}
+
+func (m *markdownItemWriter) Close() error {
+	return m.Flush()
+}
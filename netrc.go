@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var NetrcEnvVarName = "NETRC"
+
+func netrcPath() string {
+	if path := os.Getenv(NetrcEnvVarName); len(path) > 0 {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".netrc")
+}
+
+// netrcCredentials returns the login and password of the netrc entry matching
+// host (or of the default entry), a missing netrc file is not an error.
+func netrcCredentials(host string) (string, string, error) {
+	path := netrcPath()
+	if len(path) == 0 {
+		return "", "", nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	var tokens []string
+	macdef := false
+
+	s := bufio.NewScanner(f)
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		// Macro definitions continue up to the first empty line
+		if macdef {
+			macdef = len(line) > 0
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		for n, field := range fields {
+			if field == "macdef" {
+				tokens = append(tokens, fields[:n]...)
+				macdef = true
+				break
+			}
+		}
+
+		if !macdef {
+			tokens = append(tokens, fields...)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return "", "", err
+	}
+
+	var login, password string
+	var defaultLogin, defaultPassword string
+
+	match, isDefault := false, false
+
+	for n := 0; n < len(tokens); n++ {
+		switch tokens[n] {
+		case "machine":
+			if match {
+				return login, password, nil
+			}
+
+			n++
+			match, isDefault = n < len(tokens) && tokens[n] == host, false
+		case "default":
+			if match {
+				return login, password, nil
+			}
+
+			match, isDefault = false, true
+		case "login", "password", "account":
+			n++
+			if n >= len(tokens) {
+				break
+			}
+
+			switch {
+			case match && tokens[n-1] == "login":
+				login = tokens[n]
+			case match && tokens[n-1] == "password":
+				password = tokens[n]
+			case isDefault && tokens[n-1] == "login":
+				defaultLogin = tokens[n]
+			case isDefault && tokens[n-1] == "password":
+				defaultPassword = tokens[n]
+			}
+		}
+	}
+
+	if match {
+		return login, password, nil
+	}
+
+	return defaultLogin, defaultPassword, nil
+}
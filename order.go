@@ -0,0 +1,49 @@
+package main
+
+import (
+	"sort"
+)
+
+var OutputOrderValues = []string{"fetch", "oldest", "newest"}
+
+// orderedItemWriter buffers all the items in memory and writes them by
+// creation date on Close, regardless of the order they were retrieved in.
+type orderedItemWriter struct {
+	ItemWriter
+	oldest bool
+	items  []*Item
+}
+
+func newOrderedItemWriter(w ItemWriter, order string) *orderedItemWriter {
+	return &orderedItemWriter{w, order == "oldest", []*Item{}}
+}
+
+func (o *orderedItemWriter) Write(item *Item) error {
+	o.items = append(o.items, item)
+	return nil
+}
+
+// Flush is a no-op, the items are written only once all are retrieved
+func (o *orderedItemWriter) Flush() error {
+	return nil
+}
+
+func (o *orderedItemWriter) Close() error {
+	sort.SliceStable(o.items, func(i, j int) bool {
+		if o.oldest {
+			return o.items[i].CreatedAt.Before(o.items[j].CreatedAt)
+		}
+		return o.items[j].CreatedAt.Before(o.items[i].CreatedAt)
+	})
+
+	for _, item := range o.items {
+		err := o.ItemWriter.Write(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	o.items = nil
+
+	return o.ItemWriter.Close()
+}
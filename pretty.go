@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+var prettyEscaper = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// prettyItemWriter writes the items as aligned columns for the terminal, the
+// titles longer than titleWidth (when not 0) are truncated. The columns are
+// aligned on all the items, so they are written when closing.
+type prettyItemWriter struct {
+	w          *tabwriter.Writer
+	columns    []Column
+	titleWidth int
+}
+
+func newPrettyItemWriter(w io.Writer, columns []Column, titleWidth int) *prettyItemWriter {
+	return &prettyItemWriter{tabwriter.NewWriter(w, 0, 4, 2, ' ', 0), columns, titleWidth}
+}
+
+func (p *prettyItemWriter) writeRow(row []string) error {
+	_, err := io.WriteString(p.w, strings.Join(row, "\t")+"\n")
+	return err
+}
+
+func (p *prettyItemWriter) WriteHeader() error {
+	header := make([]string, len(p.columns))
+
+	for n, col := range p.columns {
+		header[n] = col.Name
+	}
+
+	return p.writeRow(header)
+}
+
+func (p *prettyItemWriter) Write(item *Item) error {
+	row := itemRow(p.columns, item, nil)
+
+	for n := range row {
+		row[n] = prettyEscaper.Replace(row[n])
+
+		if p.columns[n].Field == "title" {
+			row[n] = truncateText(row[n], p.titleWidth)
+		}
+	}
+
+	return p.writeRow(row)
+}
+
+// Flush is a no-op, flushing would align the items written so far only
+func (p *prettyItemWriter) Flush() error {
+	return nil
+}
+
+func (p *prettyItemWriter) Close() error {
+	return p.w.Flush()
+}
+
+// truncateText truncates s to width characters (when not 0) with an ellipsis
+func truncateText(s string, width int) string {
+	r := []rune(s)
+
+	if width <= 0 || len(r) <= width {
+		return s
+	}
+
+	if width == 1 {
+		return "…"
+	}
+
+	return string(r[:width-1]) + "…"
+}
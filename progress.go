@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var ProgressTTYInterval = 100 * time.Millisecond
+var ProgressLogInterval = 10 * time.Second
+
+// progressReporter prints the dump progress, the methods are no-ops on a nil
+// reporter so that the callers do not need to check if progress is enabled.
+type progressReporter struct {
+	w          io.Writer
+	tty        bool
+	repository string
+	page       int
+	items      int
+	last       time.Time
+}
+
+func newProgressReporter(f *os.File) *progressReporter {
+	tty := false
+
+	if info, err := f.Stat(); err == nil {
+		tty = info.Mode()&os.ModeCharDevice != 0
+	}
+
+	return &progressReporter{w: f, tty: tty}
+}
+
+func (p *progressReporter) print(force bool) {
+	interval := ProgressLogInterval
+
+	if p.tty {
+		interval = ProgressTTYInterval
+	}
+
+	// Nothing is printed before the first page is completed
+	if len(p.repository) == 0 || (!force && time.Since(p.last) < interval) {
+		return
+	}
+
+	p.last = time.Now()
+
+	if p.tty {
+		fmt.Fprintf(p.w, "\r%s: page %d, %d items", p.repository, p.page, p.items)
+	} else {
+		fmt.Fprintf(p.w, "%s: page %d, %d items\n", p.repository, p.page, p.items)
+	}
+}
+
+func (p *progressReporter) Page(repository string, page int) {
+	if p == nil {
+		return
+	}
+
+	p.repository, p.page = repository, page
+	p.print(false)
+}
+
+func (p *progressReporter) Item() {
+	if p == nil {
+		return
+	}
+
+	p.items++
+	p.print(false)
+}
+
+func (p *progressReporter) Done() {
+	if p == nil {
+		return
+	}
+
+	if p.tty {
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+
+	fmt.Fprintf(p.w, "Dumped %d items\n", p.items)
+}
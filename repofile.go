@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readRepositoryFile returns the owner/name repositories listed one for each
+// line in path, the empty lines and the # comments are ignored.
+func readRepositoryFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	repos := []string{}
+	s := bufio.NewScanner(f)
+
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		line = strings.TrimSpace(line)
+
+		if len(line) == 0 {
+			continue
+		}
+
+		owner, name := splitRepository(line)
+
+		if len(owner) == 0 || len(name) == 0 || strings.ContainsAny(name, "/ \t") || strings.ContainsAny(owner, " \t") {
+			return nil, fmt.Errorf("Invalid repository %q at %s:%d: the owner/name format is required", line, path, n)
+		}
+
+		repos = append(repos, line)
+	}
+
+	return repos, s.Err()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// resumeState records the dump progress so that an interrupted dump can be
+// continued with the same parameters, the methods are no-ops on a nil state.
+type resumeState struct {
+	path   string
+	writer ItemWriter
+
+	Checksum   string   `json:"checksum"`
+	Completed  []string `json:"completed"`
+	Repository string   `json:"repository"`
+	Page       int      `json:"page"`
+}
+
+// resumeChecksum identifies the dump parameters, the resume file itself is
+// excluded as well as the flags that do not change the dumped items. The
+// default -s date changes every day, it is excluded unless set explicitly so
+// that a dump can be resumed on the following days.
+func resumeChecksum() string {
+	h := sha256.New()
+
+	set := map[string]bool{}
+
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	flag.VisitAll(func(f *flag.Flag) {
+		switch f.Name {
+		case "resume-file", "progress", "quiet", "verbose", "timeout", "concurrency", "max-retries", "cache-dir", "no-cache", "config", "flush-every", "otp", "show-rate-limit", "stats", "client-cert", "client-key":
+			return
+		case "s":
+			if !set[f.Name] {
+				return
+			}
+		}
+
+		fmt.Fprintf(h, "%s=%s\n", f.Name, f.Value)
+	})
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// loadResumeState reads the resume file at path, the returned bool reports
+// whether a previous dump is being resumed.
+func loadResumeState(path string) (*resumeState, bool, error) {
+	r := &resumeState{path: path, Checksum: resumeChecksum()}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	previous := resumeState{}
+
+	err = json.Unmarshal(data, &previous)
+	if err != nil {
+		return nil, false, fmt.Errorf("Invalid resume file %s: %v", path, err)
+	}
+
+	if previous.Checksum != r.Checksum {
+		return nil, false, fmt.Errorf("The resume file %s was created with different parameters", path)
+	}
+
+	r.Completed, r.Repository, r.Page = previous.Completed, previous.Repository, previous.Page
+
+	return r, true, nil
+}
+
+func (r *resumeState) save() error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	tmp := r.path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, r.path)
+}
+
+func (r *resumeState) RepositoryCompleted(repository string) bool {
+	if r == nil {
+		return false
+	}
+
+	for _, c := range r.Completed {
+		if c == repository {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StartPage returns the first page of repository that was not dumped yet
+func (r *resumeState) StartPage(repository string) int {
+	if r == nil || r.Repository != repository {
+		return 1
+	}
+
+	return r.Page + 1
+}
+
+// PageDone records that all the items of page were written, the output is
+// flushed first so that no written item is lost when resuming.
+func (r *resumeState) PageDone(repository string, page int) error {
+	if r == nil {
+		return nil
+	}
+
+	err := r.writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	r.Repository, r.Page = repository, page
+
+	return r.save()
+}
+
+func (r *resumeState) RepositoryDone(repository string) error {
+	if r == nil {
+		return nil
+	}
+
+	err := r.writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	r.Completed = append(r.Completed, repository)
+	r.Repository, r.Page = "", 0
+
+	return r.save()
+}
+
+// Done removes the resume file once the dump completed successfully
+func (r *resumeState) Done() error {
+	if r == nil {
+		return nil
+	}
+
+	return os.Remove(r.path)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// splitItemWriter writes the items of each author to a separate CSV file in
+// dir, the files are created when the first item of the author is written.
+type splitItemWriter struct {
+	dir        string
+	columns    []Column
+	comma      rune
+	hyperlinks bool
+	header     bool
+	bom        bool
+
+	files   map[string]*os.File
+	writers map[string]*csvItemWriter
+}
+
+func newSplitItemWriter(dir string, columns []Column, comma rune, hyperlinks, header, bom bool) (*splitItemWriter, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &splitItemWriter{dir, columns, comma, hyperlinks, header, bom, map[string]*os.File{}, map[string]*csvItemWriter{}}, nil
+}
+
+func (s *splitItemWriter) path(login string) string {
+	return filepath.Join(s.dir, strings.Replace(login, string(filepath.Separator), "_", -1)+".csv")
+}
+
+func (s *splitItemWriter) writer(login string) (*csvItemWriter, error) {
+	if c, ok := s.writers[login]; ok {
+		return c, nil
+	}
+
+	f, err := os.Create(s.path(login))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.bom {
+		_, err := io.WriteString(f, UTF8BOM)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	c := newCSVItemWriter(f, s.columns, s.comma, s.hyperlinks)
+
+	if s.header {
+		err := c.WriteHeader()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	s.files[login], s.writers[login] = f, c
+
+	return c, nil
+}
+
+// WriteHeader is a no-op, the header is written when creating each file
+func (s *splitItemWriter) WriteHeader() error {
+	return nil
+}
+
+func (s *splitItemWriter) Write(item *Item) error {
+	c, err := s.writer(item.User)
+	if err != nil {
+		return err
+	}
+
+	return c.Write(item)
+}
+
+func (s *splitItemWriter) Flush() error {
+	for _, c := range s.writers {
+		err := c.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *splitItemWriter) Close() error {
+	var closeErr error
+
+	for login, c := range s.writers {
+		err := c.Close()
+		if err == nil {
+			err = s.files[login].Close()
+		}
+
+		if err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+
+	return closeErr
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var SQLiteCreateTable = `CREATE TABLE IF NOT EXISTS items (
+	repo TEXT NOT NULL,
+	user TEXT NOT NULL,
+	user_html_url TEXT NOT NULL,
+	type TEXT NOT NULL,
+	number INTEGER NOT NULL,
+	html_url TEXT NOT NULL,
+	title TEXT NOT NULL,
+	state TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	closed_at TEXT,
+	labels TEXT NOT NULL,
+	assignees TEXT NOT NULL,
+	milestone TEXT NOT NULL,
+	comments INTEGER NOT NULL,
+	body TEXT NOT NULL,
+	reactions INTEGER,
+	thumbs_up INTEGER,
+	PRIMARY KEY (repo, number)
+)`
+
+var SQLiteInsertItem = `INSERT OR REPLACE INTO items (
+	repo, user, user_html_url, type, number, html_url, title, state,
+	created_at, closed_at, labels, assignees, milestone, comments, body,
+	reactions, thumbs_up
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// sqliteItemWriter inserts the items in a SQLite database, replacing the rows
+// of a previous dump so that the database can be updated incrementally. The
+// inserts are performed in a transaction that is committed on Flush and Close.
+type sqliteItemWriter struct {
+	db   *sql.DB
+	tx   *sql.Tx
+	stmt *sql.Stmt
+}
+
+func newSQLiteItemWriter(path string) (*sqliteItemWriter, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(SQLiteCreateTable)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &sqliteItemWriter{db: db}
+
+	err = s.begin()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *sqliteItemWriter) begin() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(SQLiteInsertItem)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	s.tx, s.stmt = tx, stmt
+
+	return nil
+}
+
+func (s *sqliteItemWriter) commit() error {
+	err := s.stmt.Close()
+	if err != nil {
+		s.tx.Rollback()
+		return err
+	}
+
+	return s.tx.Commit()
+}
+
+func (s *sqliteItemWriter) WriteHeader() error {
+	return nil
+}
+
+// sqliteTime stores the dates as RFC 3339 text, which the SQLite date and
+// time functions understand, instead of using the -date-format layout.
+func sqliteTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (s *sqliteItemWriter) Write(item *Item) error {
+	var reactions, thumbsUp interface{}
+
+	if item.Reactions != nil {
+		reactions, thumbsUp = item.Reactions.Total, item.Reactions.ThumbsUp
+	}
+
+	_, err := s.stmt.Exec(
+		item.Repository, item.User, item.UserHTMLURL, item.Type, item.Number, item.HTMLURL, item.Title, item.State,
+		sqliteTime(&item.CreatedAt), sqliteTime(item.ClosedAt), strings.Join(item.Labels, ListSeparator),
+		strings.Join(item.Assignees, ListSeparator), item.Milestone, item.Comments, item.Body,
+		reactions, thumbsUp,
+	)
+
+	return err
+}
+
+func (s *sqliteItemWriter) Flush() error {
+	err := s.commit()
+	if err != nil {
+		return err
+	}
+
+	return s.begin()
+}
+
+func (s *sqliteItemWriter) Close() error {
+	err := s.commit()
+	if err != nil {
+		s.db.Close()
+		return err
+	}
+
+	return s.db.Close()
+}
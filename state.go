@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runState records the time of the last successful dump of each repository
+// (or search query) for the incremental dumps, the methods are no-ops on a
+// nil state.
+type runState struct {
+	path string
+
+	LastRuns map[string]time.Time `json:"last_runs"`
+}
+
+// loadRunState reads the state file at path, a missing file is not an error
+func loadRunState(path string) (*runState, error) {
+	r := &runState{path: path, LastRuns: map[string]time.Time{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, r)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid state file %s: %v", path, err)
+	}
+
+	if r.LastRuns == nil {
+		r.LastRuns = map[string]time.Time{}
+	}
+
+	return r, nil
+}
+
+// LastRun returns the time of the last successful dump of key
+func (r *runState) LastRun(key string) (time.Time, bool) {
+	if r == nil {
+		return time.Time{}, false
+	}
+
+	t, ok := r.LastRuns[key]
+
+	return t, ok
+}
+
+func (r *runState) Done(key string, t time.Time) {
+	if r == nil {
+		return
+	}
+
+	r.LastRuns[key] = t
+}
+
+func (r *runState) Save() error {
+	if r == nil {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := r.path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, r.path)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type summaryCount struct {
+	Key   string
+	Count int
+}
+
+type summaryCounter map[string]int
+
+func (s summaryCounter) sorted() []summaryCount {
+	counts := []summaryCount{}
+
+	for k, v := range s {
+		counts = append(counts, summaryCount{k, v})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+
+	return counts
+}
+
+type summaryItemWriter struct {
+	w       io.Writer
+	total   int
+	types   summaryCounter
+	authors summaryCounter
+}
+
+func newSummaryItemWriter(w io.Writer) *summaryItemWriter {
+	return &summaryItemWriter{w: w, types: summaryCounter{}, authors: summaryCounter{}}
+}
+
+func (s *summaryItemWriter) WriteHeader() error {
+	return nil
+}
+
+func (s *summaryItemWriter) Write(item *Item) error {
+	s.total++
+	s.types[item.Type]++
+	s.authors[item.User]++
+	return nil
+}
+
+func (s *summaryItemWriter) Flush() error {
+	return nil
+}
+
+func (s *summaryItemWriter) Close() error {
+	t := tabwriter.NewWriter(s.w, 0, 4, 2, ' ', 0)
+
+	for _, table := range []struct {
+		name    string
+		counter summaryCounter
+	}{
+		{"Type", s.types},
+		{"Author", s.authors},
+	} {
+		fmt.Fprintf(t, "%s\tCount\n", table.name)
+
+		for _, c := range table.counter.sorted() {
+			fmt.Fprintf(t, "%s\t%d\n", c.Key, c.Count)
+		}
+
+		fmt.Fprintln(t)
+	}
+
+	fmt.Fprintf(t, "Total\t%d\n", s.total)
+
+	return t.Flush()
+}
+
+var GroupByNone = "(none)"
+
+// GroupByKeys returns the keys of an item for each -group-by value, an item
+// is counted once for each of its labels or assignees.
+var GroupByKeys = map[string]func(*Item) []string{
+	"type":      func(i *Item) []string { return []string{i.Type} },
+	"author":    func(i *Item) []string { return []string{i.User} },
+	"state":     func(i *Item) []string { return []string{i.State} },
+	"label":     func(i *Item) []string { return i.Labels },
+	"assignee":  func(i *Item) []string { return i.Assignees },
+	"milestone": func(i *Item) []string { return []string{i.Milestone} },
+}
+
+func groupByValues() []string {
+	values := []string{}
+
+	for v := range GroupByKeys {
+		values = append(values, v)
+	}
+
+	sort.Strings(values)
+
+	return values
+}
+
+// groupItemWriter counts the items by the -group-by keys and writes the
+// counts as CSV on Close.
+type groupItemWriter struct {
+	w       *csv.Writer
+	groupBy string
+	header  bool
+	counter summaryCounter
+}
+
+func newGroupItemWriter(w io.Writer, groupBy string, comma rune, header bool) *groupItemWriter {
+	c := csv.NewWriter(w)
+	c.Comma = comma
+
+	return &groupItemWriter{c, groupBy, header, summaryCounter{}}
+}
+
+func (g *groupItemWriter) WriteHeader() error {
+	return nil
+}
+
+func (g *groupItemWriter) Write(item *Item) error {
+	keys := GroupByKeys[g.groupBy](item)
+
+	if len(keys) == 0 || (len(keys) == 1 && len(keys[0]) == 0) {
+		keys = []string{GroupByNone}
+	}
+
+	for _, k := range keys {
+		g.counter[k]++
+	}
+
+	return nil
+}
+
+func (g *groupItemWriter) Flush() error {
+	return nil
+}
+
+func (g *groupItemWriter) Close() error {
+	if g.header {
+		// The -group-by keys are lowercase ASCII, e.g. label
+		err := g.w.Write([]string{strings.ToUpper(g.groupBy[:1]) + g.groupBy[1:], "Count"})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range g.counter.sorted() {
+		err := g.w.Write([]string{c.Key, fmt.Sprint(c.Count)})
+		if err != nil {
+			return err
+		}
+	}
+
+	g.w.Flush()
+
+	return g.w.Error()
+}
+
+// dryRunItemWriter only counts the items by type, the pull requests data that
+// requires additional requests is not retrieved so the counts are estimates.
+type dryRunItemWriter struct {
+	w      io.Writer
+	issues int
+	prs    int
+}
+
+func newDryRunItemWriter(w io.Writer) *dryRunItemWriter {
+	return &dryRunItemWriter{w: w}
+}
+
+func (d *dryRunItemWriter) WriteHeader() error {
+	return nil
+}
+
+func (d *dryRunItemWriter) Write(item *Item) error {
+	if item.Type == TypePullRequest {
+		d.prs++
+	} else {
+		d.issues++
+	}
+	return nil
+}
+
+func (d *dryRunItemWriter) Flush() error {
+	return nil
+}
+
+func (d *dryRunItemWriter) Close() error {
+	_, err := fmt.Fprintf(d.w, "Would dump approximately %d issues and %d pull requests\n", d.issues, d.prs)
+	return err
+}
+
+// distributionItemWriter counts the values of a column and prints them with
+// their percentage of the items on Close.
+type distributionItemWriter struct {
+	w       io.Writer
+	column  Column
+	total   int
+	counter summaryCounter
+}
+
+func newDistributionItemWriter(w io.Writer, column Column) *distributionItemWriter {
+	return &distributionItemWriter{w, column, 0, summaryCounter{}}
+}
+
+func (d *distributionItemWriter) WriteHeader() error {
+	return nil
+}
+
+func (d *distributionItemWriter) Write(item *Item) error {
+	value := d.column.Value(item)
+
+	if len(value) == 0 {
+		value = GroupByNone
+	}
+
+	d.counter[value]++
+	d.total++
+
+	return nil
+}
+
+func (d *distributionItemWriter) Flush() error {
+	return nil
+}
+
+func (d *distributionItemWriter) Close() error {
+	t := tabwriter.NewWriter(d.w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(t, "%s\tCount\tPercent\n", d.column.Name)
+
+	for _, c := range d.counter.sorted() {
+		fmt.Fprintf(t, "%s\t%d\t%.1f%%\n", c.Key, c.Count, 100*float64(c.Count)/float64(d.total))
+	}
+
+	return t.Flush()
+}
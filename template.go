@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/github"
+	"io.bytenix.com/ghdump/ghdump"
+)
+
+// TemplateFuncs are the helper functions available in the -template items
+// templates in addition to the text/template builtins.
+var TemplateFuncs = template.FuncMap{
+	"date":  templateDate,
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"sql":   templateSQL,
+}
+
+// templateDate formats a date with -date-format, or with the layout when
+// specified, the nil dates are formatted as empty strings.
+func templateDate(t interface{}, layout ...string) (string, error) {
+	var d time.Time
+
+	switch v := t.(type) {
+	case time.Time:
+		d = v
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		d = *v
+	case github.Timestamp:
+		d = v.Time
+	case *github.Timestamp:
+		if v == nil {
+			return "", nil
+		}
+		d = v.Time
+	default:
+		return "", fmt.Errorf("Invalid date %v", t)
+	}
+
+	if len(layout) > 0 {
+		return d.Format(layout[0]), nil
+	}
+
+	return formatTime(&d), nil
+}
+
+// templateSQL quotes a string as an SQL literal
+func templateSQL(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// templateData is the context of the items templates, the issue fields are
+// available directly (e.g. {{.Title}} or {{.User.Login}}).
+type templateData struct {
+	*github.Issue
+	PullRequest *ghdump.PullRequest
+	Item        *Item
+}
+
+// loadTemplate parses the -template text or the -template-file content
+func loadTemplate(text, file string) (*template.Template, error) {
+	if len(file) > 0 {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		text = string(data)
+	}
+
+	t, err := template.New("ghdump").Funcs(TemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid template: %v", err)
+	}
+
+	return t, nil
+}
+
+// templateItemWriter writes each item as rendered by the template, followed
+// by a newline unless the rendered text already ends with one.
+type templateItemWriter struct {
+	w *bufio.Writer
+	t *template.Template
+}
+
+func newTemplateItemWriter(w io.Writer, t *template.Template) *templateItemWriter {
+	return &templateItemWriter{bufio.NewWriter(w), t}
+}
+
+func (t *templateItemWriter) WriteHeader() error {
+	return nil
+}
+
+func (t *templateItemWriter) Write(item *Item) error {
+	b := bytes.Buffer{}
+
+	err := t.t.Execute(&b, &templateData{item.issue, item.pullRequest, item})
+	if err != nil {
+		return err
+	}
+
+	if b.Len() == 0 || b.Bytes()[b.Len()-1] != '\n' {
+		b.WriteByte('\n')
+	}
+
+	_, err = t.w.Write(b.Bytes())
+
+	return err
+}
+
+func (t *templateItemWriter) Flush() error {
+	return t.w.Flush()
+}
+
+func (t *templateItemWriter) Close() error {
+	return t.Flush()
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+var XLSXMaxColumnWidth = 80
+
+var xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+var xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+var xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="ghdump" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+var xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// The cell styles are: 0 default, 1 bold (header), 2 hyperlink
+var xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="3"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font><font><u/><sz val="11"/><color rgb="FF0563C1"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+
+// xlsxItemWriter streams the rows to temporary files while tracking the
+// column widths, the spreadsheet is assembled only when closing because the
+// widths and the hyperlinks must be written before and after the rows.
+type xlsxItemWriter struct {
+	w          io.Writer
+	columns    []Column
+	hyperlinks bool
+	widths     []int
+	rowCount   int
+	linkCount  int
+	rows       *xlsxSpool
+	links      *xlsxSpool
+	rels       *xlsxSpool
+}
+
+type xlsxSpool struct {
+	f *os.File
+	*bufio.Writer
+}
+
+func newXLSXSpool() (*xlsxSpool, error) {
+	f, err := os.CreateTemp("", "ghdump-*.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	return &xlsxSpool{f, bufio.NewWriter(f)}, nil
+}
+
+func (s *xlsxSpool) copyTo(w io.Writer) error {
+	err := s.Flush()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.f.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, s.f)
+	return err
+}
+
+func (s *xlsxSpool) remove() {
+	s.f.Close()
+	os.Remove(s.f.Name())
+}
+
+func newXLSXItemWriter(w io.Writer, columns []Column, hyperlinks bool) (*xlsxItemWriter, error) {
+	x := &xlsxItemWriter{w: w, columns: columns, hyperlinks: hyperlinks, widths: make([]int, len(columns))}
+
+	for _, s := range []**xlsxSpool{&x.rows, &x.links, &x.rels} {
+		spool, err := newXLSXSpool()
+		if err != nil {
+			x.removeSpools()
+			return nil, err
+		}
+
+		*s = spool
+	}
+
+	return x, nil
+}
+
+func (x *xlsxItemWriter) removeSpools() {
+	for _, s := range []*xlsxSpool{x.rows, x.links, x.rels} {
+		if s != nil {
+			s.remove()
+		}
+	}
+}
+
+func xlsxColumnName(n int) string {
+	name := ""
+
+	for n++; n > 0; n = (n - 1) / 26 {
+		name = string(rune('A'+(n-1)%26)) + name
+	}
+
+	return name
+}
+
+func xlsxEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func (x *xlsxItemWriter) writeRow(values []string, links []string, style int) error {
+	x.rowCount++
+
+	fmt.Fprintf(x.rows, `<row r="%d">`, x.rowCount)
+
+	for n, v := range values {
+		ref := fmt.Sprintf("%s%d", xlsxColumnName(n), x.rowCount)
+		cellStyle := style
+
+		if links != nil && len(links[n]) > 0 {
+			x.linkCount++
+			cellStyle = 2
+
+			fmt.Fprintf(x.links, `<hyperlink ref="%s" r:id="rId%d"/>`, ref, x.linkCount)
+			fmt.Fprintf(x.rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="%s" TargetMode="External"/>`, x.linkCount, xlsxEscape(links[n]))
+		}
+
+		fmt.Fprintf(x.rows, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, cellStyle, xlsxEscape(v))
+
+		if width := utf8.RuneCountInString(v); width > x.widths[n] {
+			x.widths[n] = width
+		}
+	}
+
+	_, err := x.rows.WriteString("</row>")
+	return err
+}
+
+func (x *xlsxItemWriter) WriteHeader() error {
+	header := make([]string, len(x.columns))
+
+	for n, c := range x.columns {
+		header[n] = c.Name
+	}
+
+	return x.writeRow(header, nil, 1)
+}
+
+func (x *xlsxItemWriter) Write(item *Item) error {
+	values := make([]string, len(x.columns))
+	links := make([]string, len(x.columns))
+
+	for n, c := range x.columns {
+		values[n] = c.Value(item)
+
+		if x.hyperlinks && c.Link != nil {
+			links[n] = c.Link(item)
+		}
+	}
+
+	return x.writeRow(values, links, 0)
+}
+
+func (x *xlsxItemWriter) Flush() error {
+	for _, s := range []*xlsxSpool{x.rows, x.links, x.rels} {
+		err := s.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *xlsxItemWriter) writeSheet(w io.Writer) error {
+	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n")
+	fmt.Fprint(w, `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	fmt.Fprint(w, `<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	fmt.Fprint(w, `<cols>`)
+
+	for n, width := range x.widths {
+		if width > XLSXMaxColumnWidth {
+			width = XLSXMaxColumnWidth
+		}
+
+		fmt.Fprintf(w, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, n+1, n+1, width+2)
+	}
+
+	fmt.Fprint(w, `</cols><sheetData>`)
+
+	err := x.rows.copyTo(w)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, `</sheetData>`)
+
+	if x.linkCount > 0 {
+		fmt.Fprint(w, `<hyperlinks>`)
+
+		err := x.links.copyTo(w)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(w, `</hyperlinks>`)
+	}
+
+	_, err = fmt.Fprint(w, `</worksheet>`)
+	return err
+}
+
+func (x *xlsxItemWriter) writeSheetRels(w io.Writer) error {
+	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n")
+	fmt.Fprint(w, `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	err := x.rels.copyTo(w)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(w, `</Relationships>`)
+	return err
+}
+
+func (x *xlsxItemWriter) Close() error {
+	defer x.removeSpools()
+
+	z := zip.NewWriter(x.w)
+
+	parts := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"[Content_Types].xml", xlsxStaticPart(xlsxContentTypes)},
+		{"_rels/.rels", xlsxStaticPart(xlsxRootRels)},
+		{"xl/workbook.xml", xlsxStaticPart(xlsxWorkbook)},
+		{"xl/_rels/workbook.xml.rels", xlsxStaticPart(xlsxWorkbookRels)},
+		{"xl/styles.xml", xlsxStaticPart(xlsxStyles)},
+		{"xl/worksheets/sheet1.xml", x.writeSheet},
+		{"xl/worksheets/_rels/sheet1.xml.rels", x.writeSheetRels},
+	}
+
+	for _, p := range parts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return err
+		}
+
+		err = p.write(f)
+		if err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+func xlsxStaticPart(content string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, content)
+		return err
+	}
+}